@@ -1,8 +1,12 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -10,6 +14,7 @@ import (
 
 	"github.com/trading-system/execution-engine/internal/exchange"
 	"github.com/trading-system/execution-engine/internal/order"
+	"github.com/trading-system/execution-engine/internal/rebalance"
 	"github.com/trading-system/execution-engine/internal/reconciliation"
 	"github.com/trading-system/execution-engine/internal/risk"
 	"github.com/trading-system/execution-engine/internal/stream"
@@ -37,19 +42,50 @@ func main() {
 	go metricsServer.Start()
 
 	// Initialize risk controller client
-	riskClient := risk.NewClient("http://localhost:8080") // Update with actual risk controller URL
+	riskURL := "http://localhost:8080" // Update with actual risk controller URL
+	riskClient := risk.NewClient(riskURL)
 
 	// Initialize exchanges
 	binance := exchange.NewBinanceClient()
 	kraken := exchange.NewKrakenClient()
 	coinbase := exchange.NewCoinbaseClient()
 
-	// Create exchange manager
-	exchangeManager := exchange.NewManager(map[string]exchange.Interface{
-		"binance": binance,
-		"kraken":  kraken,
+	// Create exchange manager and connect venues. A venue that fails to
+	// connect can be registered later without a restart.
+	exchangeManager := exchange.NewManager(nil)
+	for name, ex := range map[string]exchange.Interface{
+		"binance":  binance,
+		"kraken":   kraken,
 		"coinbase": coinbase,
-	})
+	} {
+		// Staging sets EXCHANGE_ENV=sandbox to trade on testnets
+		cfg, err := exchange.LoadEnvironmentConfig(name)
+		if err != nil {
+			log.Fatalf("Invalid %s configuration: %v", name, err)
+		}
+		configurable, ok := ex.(exchange.Configurable)
+		if !ok {
+			log.Printf("Skipping %s: its environment cannot be configured", name)
+			continue
+		}
+		if err := configurable.Configure(cfg); err != nil {
+			log.Printf("Failed to configure %s for %s: %v", name, cfg.Environment, err)
+			continue
+		}
+		if err := exchangeManager.Register(name, ex); err != nil {
+			log.Printf("Failed to register %s: %v", name, err)
+		}
+	}
+
+	// Track venue health and connectivity for the circuit breakers
+	go exchangeManager.MonitorHealth(ctx)
+
+	// Keep tick size, lot size and min notional rules up to date
+	go exchangeManager.RunInstrumentRefresh(ctx, time.Hour)
+
+	// Have the venues cancel our resting orders a minute after the engine
+	// stops heartbeating, whether it crashed or was shut down
+	go exchangeManager.RunDeadMansSwitch(ctx, time.Minute, 15*time.Second)
 
 	// Initialize WebSocket stream aggregator
 	streamAggregator := stream.NewAggregator(exchangeManager)
@@ -63,9 +99,28 @@ func main() {
 	reconciler := reconciliation.NewReconciler(exchangeManager, dbConn)
 	go reconciler.Run(ctx, 5*time.Minute) // Reconcile every 5 minutes
 
+	// Move inventory between venues to keep it at target levels. Transfers
+	// are only logged and recorded until dry_run is turned off.
+	rebalanceConfig, err := rebalance.LoadConfig()
+	if err != nil {
+		log.Fatalf("Invalid rebalancing configuration: %v", err)
+	}
+	if len(rebalanceConfig.Targets) == 0 || len(rebalanceConfig.Routes) == 0 {
+		log.Println("Rebalancing disabled: no targets or routes in REBALANCE_CONFIG")
+	} else {
+		rebalancer := rebalance.NewRebalancer(exchangeManager, dbConn, rebalanceConfig)
+		go rebalancer.Run(ctx, 15*time.Minute)
+	}
+
+	// Size the risk controller's limits from the value held across venues
+	go reportAccountBalance(ctx, exchangeManager, riskURL, time.Minute)
+
 	// Start order processing
 	go orderManager.ProcessOrders(ctx)
 
+	// Track fills and status changes from the private exchange streams
+	go orderManager.HandleExecutions(ctx)
+
 	// Setup graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -73,4 +128,55 @@ func main() {
 	log.Println("Shutting down execution engine...")
 	cancel()
 	time.Sleep(2 * time.Second) // Allow goroutines to clean up
-}
\ No newline at end of file
+}
+
+// reportAccountBalance sends the USD value of the portfolio across all
+// venues to the risk controller at every interval. Snapshots missing an
+// exchange are skipped so that an outage does not shrink the limits.
+func reportAccountBalance(ctx context.Context, em *exchange.Manager, riskURL string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		portfolio := em.Portfolio(ctx)
+		if len(portfolio.Errors) > 0 {
+			log.Printf("Not reporting account balance: %d exchanges could not be read", len(portfolio.Errors))
+		} else {
+			value, unpriced := em.PortfolioValue(ctx, portfolio, "USD")
+			if len(unpriced) > 0 {
+				log.Printf("Account balance excludes unpriced assets %v", unpriced)
+			}
+			if err := putAccountBalance(ctx, riskURL, value); err != nil {
+				log.Printf("Failed to report account balance: %v", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func putAccountBalance(ctx context.Context, riskURL string, value float64) error {
+	body, err := json.Marshal(map[string]float64{"account_balance": value})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, riskURL+"/risk_params", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("risk controller returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
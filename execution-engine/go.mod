@@ -4,6 +4,7 @@ go 1.22
 
 require (
 	github.com/adshao/go-binance/v2 v2.4.1
+	github.com/gorilla/websocket v1.5.0
 	github.com/krakenfx/kraken-go v1.0.0
 	github.com/preichenberger/go-coinbasepro/v2 v2.1.0
 	github.com/prometheus/client_golang v1.17.0
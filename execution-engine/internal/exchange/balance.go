@@ -0,0 +1,185 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Balance is the holding of a single asset on an exchange
+type Balance struct {
+	Exchange  string
+	Asset     string // canonical asset code, e.g. BTC rather than XXBT
+	Free      float64
+	Locked    float64 // reserved by open orders, margin or withdrawals
+	Total     float64
+	Timestamp time.Time
+}
+
+// BalanceProvider is implemented by exchanges that can report every asset
+// in the account at once
+type BalanceProvider interface {
+	// GetBalances returns all assets with a non-zero total
+	GetBalances(ctx context.Context) ([]Balance, error)
+}
+
+// Portfolio is a point-in-time view of balances across exchanges
+type Portfolio struct {
+	Balances  []Balance          // per exchange and asset
+	Totals    map[string]Balance // per asset, summed across exchanges
+	Errors    map[string]error   // exchanges whose balances could not be read
+	Timestamp time.Time
+}
+
+// stablecoins are valued at par against USD quotes
+var stablecoins = map[string]bool{"USD": true, "USDT": true, "USDC": true, "BUSD": true}
+
+// Value returns the total value of the portfolio in the quote currency. The
+// price function returns the price of an asset in the quote currency;
+// assets it cannot price are returned separately and excluded.
+func (p *Portfolio) Value(quote string, price func(asset string) (float64, bool)) (float64, []string) {
+	quote = strings.ToUpper(quote)
+
+	var value float64
+	var unpriced []string
+	for asset, bal := range p.Totals {
+		switch {
+		case asset == quote, stablecoins[asset] && stablecoins[quote]:
+			value += bal.Total
+		default:
+			px, ok := price(asset)
+			if !ok {
+				unpriced = append(unpriced, asset)
+				continue
+			}
+			value += bal.Total * px
+		}
+	}
+	sort.Strings(unpriced)
+	return value, unpriced
+}
+
+// GetBalances returns all balances on the specified exchange
+func (m *Manager) GetBalances(ctx context.Context, exchangeName string) ([]Balance, error) {
+	ex, ok := m.GetExchange(exchangeName)
+	if !ok {
+		return nil, ErrExchangeNotFound
+	}
+	provider, ok := ex.(BalanceProvider)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+
+	balances, err := provider.GetBalances(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range balances {
+		balances[i].Exchange = exchangeName
+	}
+	return balances, nil
+}
+
+// Portfolio reads balances from every exchange concurrently. Exchanges that
+// fail are reported in Errors rather than failing the whole snapshot.
+func (m *Manager) Portfolio(ctx context.Context) *Portfolio {
+	p := &Portfolio{
+		Totals:    make(map[string]Balance),
+		Errors:    make(map[string]error),
+		Timestamp: time.Now(),
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for name, ex := range m.GetAllExchanges() {
+		if _, ok := ex.(BalanceProvider); !ok {
+			continue
+		}
+
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			balances, err := m.GetBalances(ctx, name)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				p.Errors[name] = fmt.Errorf("%s: %w", name, err)
+				log.Printf("Error reading %s balances: %v", name, err)
+				return
+			}
+			p.Balances = append(p.Balances, balances...)
+		}(name)
+	}
+	wg.Wait()
+
+	sort.Slice(p.Balances, func(i, j int) bool {
+		if p.Balances[i].Exchange != p.Balances[j].Exchange {
+			return p.Balances[i].Exchange < p.Balances[j].Exchange
+		}
+		return p.Balances[i].Asset < p.Balances[j].Asset
+	})
+	for _, b := range p.Balances {
+		total := p.Totals[b.Asset]
+		total.Asset = b.Asset
+		total.Free += b.Free
+		total.Locked += b.Locked
+		total.Total += b.Total
+		if b.Timestamp.After(total.Timestamp) {
+			total.Timestamp = b.Timestamp
+		}
+		p.Totals[b.Asset] = total
+	}
+	return p
+}
+
+// valuationQuotes are tried in order when pricing assets in a stablecoin
+var valuationQuotes = []string{"USDT", "USD", "USDC"}
+
+// PortfolioValue values a portfolio in a quote currency, pricing each asset
+// at the latest one-minute close on the first exchange that lists it. For
+// stablecoin quotes any stablecoin market will do. Assets no exchange could
+// price are returned separately and excluded.
+func (m *Manager) PortfolioValue(ctx context.Context, p *Portfolio, quote string) (float64, []string) {
+	quote = strings.ToUpper(quote)
+	quotes := []string{quote}
+	if stablecoins[quote] {
+		quotes = valuationQuotes
+	}
+	return p.Value(quote, func(asset string) (float64, bool) {
+		return m.lastPrice(ctx, asset, quotes)
+	})
+}
+
+// lastPrice returns the close of the latest one-minute kline of an asset
+// against the first of the quotes some exchange serves
+func (m *Manager) lastPrice(ctx context.Context, asset string, quotes []string) (float64, bool) {
+	end := time.Now()
+	start := end.Add(-5 * time.Minute)
+	for _, name := range m.List() {
+		for _, quote := range quotes {
+			var price float64
+			err := m.FetchKlines(ctx, name, asset+"/"+quote, time.Minute, start, end, func(klines []Kline) error {
+				price = klines[len(klines)-1].Close
+				return nil
+			})
+			if err == nil && price > 0 {
+				return price, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// canonicalAsset maps venue asset codes onto their common names
+func canonicalAsset(asset string) string {
+	asset = strings.ToUpper(asset)
+	if alias, ok := assetAliases[asset]; ok {
+		return alias
+	}
+	return asset
+}
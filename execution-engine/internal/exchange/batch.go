@@ -0,0 +1,110 @@
+package exchange
+
+import (
+	"context"
+	"sync"
+
+	"github.com/trading-system/execution-engine/internal/order"
+)
+
+// batchConcurrency bounds the in-flight calls of a batch on exchanges
+// without a native batch endpoint
+const batchConcurrency = 10
+
+// OrderResult is the outcome of one order of a batch placement
+type OrderResult struct {
+	ID  string // exchange order ID, empty if placement failed
+	Err error
+}
+
+// BatchOrderer is implemented by exchanges with native batch endpoints.
+// Results are in the order of the input and each order succeeds or fails
+// on its own.
+type BatchOrderer interface {
+	PlaceOrders(ctx context.Context, orders []*order.Order) []OrderResult
+	CancelOrders(ctx context.Context, orderIDs []string) []error
+}
+
+// PlaceOrders places a group of orders on an exchange, in native batches
+// where the exchange supports them and concurrently otherwise. The error is
+// only set when no order reached the exchange, e.g. while its circuit is
+// open; otherwise each result carries its own error.
+func (m *Manager) PlaceOrders(ctx context.Context, exchangeName string, orders []*order.Order) ([]OrderResult, error) {
+	var results []OrderResult
+	err := m.call(exchangeName, func(ex Interface) error {
+		if batcher, ok := ex.(BatchOrderer); ok {
+			results = batcher.PlaceOrders(ctx, orders)
+			for i := range results {
+				results[i].Err = placementError(results[i].Err)
+			}
+		} else {
+			results = make([]OrderResult, len(orders))
+			concurrently(len(orders), func(i int) {
+				results[i].ID, results[i].Err = ex.PlaceOrder(ctx, orders[i])
+				results[i].Err = placementError(results[i].Err)
+			})
+		}
+		errs := make([]error, len(results))
+		for i, r := range results {
+			errs[i] = r.Err
+		}
+		return batchErr(errs)
+	})
+	if results == nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// CancelOrders cancels a group of orders on an exchange, in native batches
+// where the exchange supports them and concurrently otherwise. Like
+// PlaceOrders, the error is only set when no cancel reached the exchange.
+func (m *Manager) CancelOrders(ctx context.Context, exchangeName string, orderIDs []string) ([]error, error) {
+	var errs []error
+	err := m.call(exchangeName, func(ex Interface) error {
+		if batcher, ok := ex.(BatchOrderer); ok {
+			errs = batcher.CancelOrders(ctx, orderIDs)
+		} else {
+			errs = make([]error, len(orderIDs))
+			concurrently(len(orderIDs), func(i int) {
+				errs[i] = ex.CancelOrder(orderIDs[i])
+			})
+		}
+		return batchErr(errs)
+	})
+	if errs == nil {
+		return nil, err
+	}
+	return errs, nil
+}
+
+// batchErr returns the first error of a batch if every item failed, so that
+// a venue rejecting some orders does not count against its circuit breaker
+func batchErr(errs []error) error {
+	for _, err := range errs {
+		if err == nil {
+			return nil
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs[0]
+}
+
+// concurrently calls fn for 0..n-1 with at most batchConcurrency calls in
+// flight and waits for all of them
+func concurrently(n int, fn func(i int)) {
+	sem := make(chan struct{}, batchConcurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
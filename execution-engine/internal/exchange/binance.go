@@ -2,35 +2,206 @@ package exchange
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/adshao/go-binance/v2"
+	"github.com/adshao/go-binance/v2/common"
 	"github.com/adshao/go-binance/v2/futures"
+	"github.com/trading-system/execution-engine/internal/order"
 )
 
 // BinanceClient implements the exchange interface for Binance
 type BinanceClient struct {
 	client      *futures.Client
-	streams     map[string]chan TradeEvent
+	wallet      *binance.Client // spot API, for deposits and withdrawals
+	streams     map[string]*binanceTradeStream
 	streamMutex sync.Mutex
+	orders      map[string]string // exchange order ID -> symbol
+	armed       map[string]bool   // symbols with a countdown running
+	orderMutex  sync.RWMutex
+	limiter     *RateLimiter
+	events      connectionEvents
 	connected   bool
 }
 
+// binanceTradeStream is a trade subscription whose channel outlives the
+// websocket connections feeding it
+type binanceTradeStream struct {
+	events chan TradeEvent
+	cancel context.CancelFunc
+}
+
+// binanceRateLimits are the USDⓈ-M futures IP and account limits
+var binanceRateLimits = []RateLimit{
+	{Class: ClassRequests, Limit: 2400, Interval: time.Minute},
+	{Class: ClassOrders, Limit: 300, Interval: 10 * time.Second},
+	{Class: ClassOrders, Limit: 1200, Interval: time.Minute},
+}
+
+// binanceWalletRateLimits is the SAPI IP limit shared by the wallet endpoints
+var binanceWalletRateLimits = []RateLimit{
+	{Class: ClassRequests, Limit: 12000, Interval: time.Minute},
+}
+
+// AssetBalance is the futures wallet state of a single asset
+type AssetBalance struct {
+	Asset              string
+	WalletBalance      float64
+	AvailableBalance   float64
+	CrossWalletBalance float64
+	UnrealizedPnL      float64
+	MaxWithdrawAmount  float64
+}
+
 // NewBinanceClient creates a new Binance client
 func NewBinanceClient() *BinanceClient {
-	return &BinanceClient{
+	b := &BinanceClient{
 		client:  futures.NewClient("", ""), // API keys will be set via config
-		streams: make(map[string]chan TradeEvent),
+		streams: make(map[string]*binanceTradeStream),
+		orders:  make(map[string]string),
+		armed:   make(map[string]bool),
+		limiter: NewRateLimiter("binance", binanceRateLimits...),
+	}
+	b.client.HTTPClient = newRateLimitedClient(b.limiter, binanceRequestCosts, observeBinanceUsage)
+
+	// Wallet endpoints are weighted separately from the futures API
+	b.wallet = binance.NewClient("", "")
+	b.wallet.HTTPClient = newRateLimitedClient(NewRateLimiter("binance", binanceWalletRateLimits...), binanceRequestCosts, nil)
+	return b
+}
+
+// RateLimiter returns the limiter shared by all Binance REST calls
+func (b *BinanceClient) RateLimiter() *RateLimiter {
+	return b.limiter
+}
+
+// binanceEndpoints are the USDⓈ-M futures deployments. go-binance picks the
+// websocket host with a process-wide testnet switch, so websockets can only
+// use these two hosts.
+var binanceEndpoints = map[Environment]Endpoints{
+	Production: {REST: "https://fapi.binance.com", Websocket: "wss://fstream.binance.com/ws"},
+	Sandbox:    {REST: "https://testnet.binancefuture.com", Websocket: "wss://stream.binancefuture.com/ws"},
+}
+
+// binanceWalletURLs are the spot API hosts serving deposits and withdrawals
+var binanceWalletURLs = map[Environment]string{
+	Production: "https://api.binance.com",
+	Sandbox:    "https://testnet.binance.vision",
+}
+
+// Configure selects the futures deployment and sets the API keys. Selecting
+// the testnet switches the websockets of every Binance client in the
+// process, since go-binance only supports a global switch.
+func (b *BinanceClient) Configure(cfg EnvironmentConfig) error {
+	endpoints, err := resolveEndpoints("binance", binanceEndpoints, cfg)
+	if err != nil {
+		return err
+	}
+	if endpoints.Websocket != binanceEndpoints[cfg.Environment].Websocket {
+		return fmt.Errorf("%w: binance websocket URL cannot be overridden", ErrNotSupported)
+	}
+	futures.UseTestnet = cfg.Environment == Sandbox
+
+	b.client.APIKey = cfg.APIKey
+	b.client.SecretKey = cfg.APISecret
+	b.client.BaseURL = strings.TrimRight(endpoints.REST, "/")
+
+	// A custom server stands in for both APIs
+	b.wallet.APIKey = cfg.APIKey
+	b.wallet.SecretKey = cfg.APISecret
+	b.wallet.BaseURL = binanceWalletURLs[cfg.Environment]
+	if cfg.Endpoints.REST != "" {
+		b.wallet.BaseURL = b.client.BaseURL
+	}
+	return nil
+}
+
+// binanceRequestCosts returns the documented weight of a futures endpoint
+func binanceRequestCosts(req *http.Request) []Cost {
+	path := req.URL.Path
+	switch {
+	case path == "/fapi/v1/order" && (req.Method == http.MethodPost || req.Method == http.MethodPut):
+		return []Cost{{ClassOrders, 1}}
+	case path == "/fapi/v1/batchOrders" && req.Method == http.MethodPost:
+		return []Cost{{ClassRequests, 5}, {ClassOrders, 5}}
+	case path == "/fapi/v2/balance", path == "/fapi/v2/account":
+		return []Cost{{ClassRequests, 5}}
+	case path == "/fapi/v1/countdownCancelAll":
+		return []Cost{{ClassRequests, 10}}
+	case path == "/fapi/v1/openOrders" && req.URL.Query().Get("symbol") == "":
+		return []Cost{{ClassRequests, 40}}
+	case path == "/fapi/v1/aggTrades":
+		return []Cost{{ClassRequests, 20}}
+	case path == "/fapi/v1/klines":
+		limit, _ := strconv.Atoi(req.URL.Query().Get("limit"))
+		switch {
+		case limit > 0 && limit < 100:
+			return []Cost{{ClassRequests, 1}}
+		case limit > 0 && limit < 500:
+			return []Cost{{ClassRequests, 2}}
+		case limit > 0 && limit <= 1000:
+			return []Cost{{ClassRequests, 5}}
+		default:
+			return []Cost{{ClassRequests, 10}}
+		}
+	case path == "/fapi/v1/depth":
+		limit, _ := strconv.Atoi(req.URL.Query().Get("limit"))
+		switch {
+		case limit > 0 && limit <= 50:
+			return []Cost{{ClassRequests, 2}}
+		case limit > 0 && limit <= 100:
+			return []Cost{{ClassRequests, 5}}
+		case limit > 0 && limit <= 500:
+			return []Cost{{ClassRequests, 10}}
+		default:
+			return []Cost{{ClassRequests, 20}}
+		}
+	}
+	return []Cost{{ClassRequests, 1}}
+}
+
+// observeBinanceUsage reads X-MBX-USED-WEIGHT-<interval> and
+// X-MBX-ORDER-COUNT-<interval> response headers
+func observeBinanceUsage(l *RateLimiter, header http.Header) {
+	for key, values := range header {
+		key = strings.ToUpper(key)
+		var class EndpointClass
+		switch {
+		case strings.HasPrefix(key, "X-MBX-USED-WEIGHT-"):
+			class = ClassRequests
+		case strings.HasPrefix(key, "X-MBX-ORDER-COUNT-"):
+			class = ClassOrders
+		default:
+			continue
+		}
+		interval, ok := parseLimitInterval(key[strings.LastIndex(key, "-")+1:])
+		if !ok || len(values) == 0 {
+			continue
+		}
+		if used, err := strconv.ParseFloat(values[0], 64); err == nil {
+			l.Observe(class, interval, used)
+		}
 	}
 }
 
 // Connect establishes connection to Binance
 func (b *BinanceClient) Connect() error {
 	// Test connectivity
-	_, err := b.client.NewPingService().Do(context.Background())
+	err := b.client.NewPingService().Do(context.Background())
 	if err != nil {
 		return err
 	}
@@ -44,8 +215,9 @@ func (b *BinanceClient) Disconnect() error {
 	b.streamMutex.Lock()
 	defer b.streamMutex.Unlock()
 	
-	for symbol := range b.streams {
-		close(b.streams[symbol])
+	// Each stream closes its channel once its connection has shut down
+	for symbol, s := range b.streams {
+		s.cancel()
 		delete(b.streams, symbol)
 	}
 	b.connected = false
@@ -58,32 +230,1095 @@ func (b *BinanceClient) PlaceOrder(ctx context.Context, o *order.Order) (string,
 		return "", ErrNotConnected
 	}
 
-	orderType := binance.OrderTypeLimit
-	if o.Type == order.Market {
-		orderType = binance.OrderTypeMarket
+	symbol := nativeSymbol(b, o.Symbol)
+	res, err := b.newOrderService(symbol, o).Do(ctx)
+	if err != nil {
+		return "", binanceError(err)
 	}
 
-	side := binance.SideTypeBuy
+	orderID := strconv.FormatInt(res.OrderID, 10)
+	b.trackOrder(orderID, symbol)
+	return orderID, nil
+}
+
+// newOrderService builds the request placing an order, for single and
+// batch placement alike
+func (b *BinanceClient) newOrderService(symbol string, o *order.Order) *futures.CreateOrderService {
+	side := futures.SideTypeBuy
 	if o.Side == order.Sell {
-		side = binance.SideTypeSell
+		side = futures.SideTypeSell
 	}
 
-	res, err := b.client.NewCreateOrderService().
-		Symbol(o.Symbol).
+	svc := b.client.NewCreateOrderService().
+		Symbol(symbol).
 		Side(side).
-		Type(orderType).
-		TimeInForce(binance.TimeInForceTypeGTC).
-		Quantity(o.QuantityString()).
-		Price(o.PriceString()).
-		Do(ctx)
+		Quantity(o.QuantityString())
+
+	if o.Type == order.Market {
+		svc = svc.Type(futures.OrderTypeMarket)
+	} else {
+		svc = svc.Type(futures.OrderTypeLimit).
+			TimeInForce(futures.TimeInForceTypeGTC).
+			Price(o.PriceString())
+	}
+	if o.ReduceOnly {
+		svc = svc.ReduceOnly(true)
+	}
+	switch o.PositionSide {
+	case order.PositionLong:
+		svc = svc.PositionSide(futures.PositionSideTypeLong)
+	case order.PositionShort:
+		svc = svc.PositionSide(futures.PositionSideTypeShort)
+	}
+	return svc
+}
+
+// Binance accepts at most 5 orders per batch placement and 10 per batch cancel
+const (
+	binancePlaceBatchSize  = 5
+	binanceCancelBatchSize = 10
+)
+
+// PlaceOrders places orders through the batchOrders endpoint, 5 at a time.
+// Entries are never resent: one missing from a response may have been
+// placed, so it fails with ErrUnknownOutcome.
+func (b *BinanceClient) PlaceOrders(ctx context.Context, orders []*order.Order) []OrderResult {
+	results := make([]OrderResult, len(orders))
+	if !b.connected {
+		for i := range results {
+			results[i].Err = ErrNotConnected
+		}
+		return results
+	}
+
+	for start := 0; start < len(orders); start += binancePlaceBatchSize {
+		end := min(start+binancePlaceBatchSize, len(orders))
+		entries := make([]map[string]string, 0, end-start)
+		for i := start; i < end; i++ {
+			entries = append(entries, binanceBatchEntry(nativeSymbol(b, orders[i].Symbol), orders[i]))
+		}
+		batch, _ := json.Marshal(entries)
+
+		// go-binance drops rejected entries along with their error, so the
+		// request is signed here to read every entry's outcome
+		params := url.Values{}
+		params.Set("batchOrders", string(batch))
+		var res []json.RawMessage
+		if err := b.signedRequest(ctx, http.MethodPost, "/fapi/v1/batchOrders", params, &res); err != nil {
+			for i := start; i < end; i++ {
+				results[i].Err = err
+			}
+			continue
+		}
+
+		// Entries are answered in the order they were sent
+		for i := start; i < end; i++ {
+			if i-start >= len(res) {
+				results[i].Err = fmt.Errorf("%w: binance: no result for batch entry %d", ErrUnknownOutcome, i-start)
+				continue
+			}
+			results[i].ID, results[i].Err = b.batchResult(res[i-start])
+		}
+	}
+	return results
+}
 
+// binanceBatchEntry returns the batchOrders entry of an order, with the same
+// fields newOrderService sets
+func binanceBatchEntry(symbol string, o *order.Order) map[string]string {
+	entry := map[string]string{
+		"symbol":   symbol,
+		"side":     string(futures.SideTypeBuy),
+		"quantity": o.QuantityString(),
+	}
+	if o.Side == order.Sell {
+		entry["side"] = string(futures.SideTypeSell)
+	}
+
+	if o.Type == order.Market {
+		entry["type"] = string(futures.OrderTypeMarket)
+	} else {
+		entry["type"] = string(futures.OrderTypeLimit)
+		entry["timeInForce"] = string(futures.TimeInForceTypeGTC)
+		entry["price"] = o.PriceString()
+	}
+	if o.ReduceOnly {
+		entry["reduceOnly"] = "true"
+	}
+	switch o.PositionSide {
+	case order.PositionLong:
+		entry["positionSide"] = string(futures.PositionSideTypeLong)
+	case order.PositionShort:
+		entry["positionSide"] = string(futures.PositionSideTypeShort)
+	}
+	return entry
+}
+
+// batchResult decodes one entry of a batchOrders response, which is either
+// the placed order or the error that rejected it
+func (b *BinanceClient) batchResult(data json.RawMessage) (string, error) {
+	apiErr := new(common.APIError)
+	if err := json.Unmarshal(data, apiErr); err == nil && apiErr.Code != 0 {
+		return "", binanceError(apiErr)
+	}
+
+	var o futures.Order
+	if err := json.Unmarshal(data, &o); err != nil || o.OrderID == 0 {
+		return "", fmt.Errorf("%w: binance: unexpected batch entry %s", ErrUnknownOutcome, data)
+	}
+	orderID := strconv.FormatInt(o.OrderID, 10)
+	b.trackOrder(orderID, o.Symbol)
+	return orderID, nil
+}
+
+// CancelOrders cancels orders through the batchOrders endpoint, grouped by
+// symbol and 10 at a time
+func (b *BinanceClient) CancelOrders(ctx context.Context, orderIDs []string) []error {
+	errs := make([]error, len(orderIDs))
+	if !b.connected {
+		for i := range errs {
+			errs[i] = ErrNotConnected
+		}
+		return errs
+	}
+
+	// Indexes of the orders to cancel by symbol
+	bySymbol := make(map[string][]int)
+	ids := make([]int64, len(orderIDs))
+	for i, orderID := range orderIDs {
+		symbol, id, err := b.lookupOrder(orderID)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		ids[i] = id
+		bySymbol[symbol] = append(bySymbol[symbol], i)
+	}
+
+	for symbol, indexes := range bySymbol {
+		for start := 0; start < len(indexes); start += binanceCancelBatchSize {
+			chunk := indexes[start:min(start+binanceCancelBatchSize, len(indexes))]
+			list := make([]int64, len(chunk))
+			for j, i := range chunk {
+				list[j] = ids[i]
+			}
+
+			res, err := b.client.NewCancelMultipleOrdersService().
+				Symbol(symbol).
+				OrderIDList(list).
+				Do(ctx)
+			if err != nil {
+				err = binanceError(err)
+				for _, i := range chunk {
+					errs[i] = err
+				}
+				continue
+			}
+
+			// Entries are in request order; failed ones carry no order ID
+			for j, i := range chunk {
+				if j < len(res) && res[j].OrderID == ids[i] {
+					continue
+				}
+				errs[i] = b.CancelOrder(orderIDs[i])
+			}
+		}
+	}
+	return errs
+}
+
+// CancelOrder cancels an open order on Binance
+func (b *BinanceClient) CancelOrder(orderID string) error {
+	if !b.connected {
+		return ErrNotConnected
+	}
+
+	symbol, id, err := b.lookupOrder(orderID)
 	if err != nil {
+		return err
+	}
+
+	res, err := b.client.NewCancelOrderService().
+		Symbol(symbol).
+		OrderID(id).
+		Do(context.Background())
+	if err != nil {
+		return binanceError(err)
+	}
+	if binanceTerminal(res.Status) {
+		b.untrackOrder(orderID)
+	}
+	return nil
+}
+
+// ModifyOrder amends the price and quantity of a working limit order in
+// place. Binance keeps the order ID but moves the order to the back of the
+// queue at its price.
+func (b *BinanceClient) ModifyOrder(ctx context.Context, o *order.Order, price, quantity float64) (string, error) {
+	if !b.connected {
+		return "", ErrNotConnected
+	}
+
+	symbol, id, err := b.lookupOrder(o.ID)
+	if err != nil {
+		return "", err
+	}
+	side := futures.SideTypeBuy
+	if o.Side == order.Sell {
+		side = futures.SideTypeSell
+	}
+
+	// go-binance has no modify service, so the request is signed here
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("orderId", strconv.FormatInt(id, 10))
+	params.Set("side", string(side))
+	params.Set("quantity", strconv.FormatFloat(quantity, 'f', -1, 64))
+	params.Set("price", strconv.FormatFloat(price, 'f', -1, 64))
+
+	var res futures.Order
+	if err := b.signedRequest(ctx, http.MethodPut, "/fapi/v1/order", params, &res); err != nil {
 		return "", err
 	}
-	return res.OrderID, nil
+	return strconv.FormatInt(res.OrderID, 10), nil
+}
+
+// signedRequest calls a signed futures endpoint the way go-binance does,
+// through the same rate limited HTTP client
+func (b *BinanceClient) signedRequest(ctx context.Context, method, path string, params url.Values, out interface{}) error {
+	params.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli()-b.client.TimeOffset, 10))
+	mac := hmac.New(sha256.New, []byte(b.client.SecretKey))
+	mac.Write([]byte(params.Encode()))
+	query := params.Encode() + "&signature=" + hex.EncodeToString(mac.Sum(nil))
+
+	req, err := http.NewRequestWithContext(ctx, method, b.client.BaseURL+path+"?"+query, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-MBX-APIKEY", b.client.APIKey)
+
+	resp, err := b.client.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := new(common.APIError)
+		if err := json.Unmarshal(data, apiErr); err != nil || apiErr.Code == 0 {
+			// Gateway errors leave the outcome of the request unknown
+			if resp.StatusCode == http.StatusServiceUnavailable || resp.StatusCode == http.StatusGatewayTimeout {
+				return fmt.Errorf("%w: binance: HTTP %d", ErrUnknownOutcome, resp.StatusCode)
+			}
+			return fmt.Errorf("binance: HTTP %d: %s", resp.StatusCode, data)
+		}
+		return binanceError(apiErr)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// CancelAllAfter renews the countdown of every symbol with open orders,
+// including orders placed elsewhere on the account, and of every symbol
+// armed before so that a countdown never runs out while the engine is up.
+// Binance counts down per symbol, so orders on a new symbol are only
+// covered from the next heartbeat.
+func (b *BinanceClient) CancelAllAfter(ctx context.Context, timeout time.Duration) error {
+	if !b.connected {
+		return ErrNotConnected
+	}
+
+	var errs []error
+	var open []struct {
+		Symbol string `json:"symbol"`
+	}
+	if err := b.signedRequest(ctx, http.MethodGet, "/fapi/v1/openOrders", url.Values{}, &open); err != nil {
+		// Still renew the symbols known locally
+		errs = append(errs, fmt.Errorf("open orders: %w", err))
+	}
+
+	b.orderMutex.Lock()
+	for _, o := range open {
+		b.armed[o.Symbol] = true
+	}
+	for _, symbol := range b.orders {
+		b.armed[symbol] = true
+	}
+	symbols := make([]string, 0, len(b.armed))
+	for symbol := range b.armed {
+		symbols = append(symbols, symbol)
+	}
+	if timeout == 0 {
+		b.armed = make(map[string]bool)
+	}
+	b.orderMutex.Unlock()
+
+	for _, symbol := range symbols {
+		params := url.Values{}
+		params.Set("symbol", symbol)
+		params.Set("countdownTime", strconv.FormatInt(timeout.Milliseconds(), 10))
+		if err := b.signedRequest(ctx, http.MethodPost, "/fapi/v1/countdownCancelAll", params, nil); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", symbol, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// GetOrderStatus returns the current status of an order on Binance
+func (b *BinanceClient) GetOrderStatus(orderID string) (order.Status, error) {
+	if !b.connected {
+		return order.Failed, ErrNotConnected
+	}
+
+	symbol, id, err := b.lookupOrder(orderID)
+	if err != nil {
+		return order.Failed, err
+	}
+
+	res, err := b.client.NewGetOrderService().
+		Symbol(symbol).
+		OrderID(id).
+		Do(context.Background())
+	if err != nil {
+		return order.Failed, binanceError(err)
+	}
+	if binanceTerminal(res.Status) {
+		b.untrackOrder(orderID)
+	}
+	return binanceOrderStatus(res.Status), nil
+}
+
+// SetLeverage sets the initial leverage of a symbol
+func (b *BinanceClient) SetLeverage(ctx context.Context, symbol string, leverage int) error {
+	if !b.connected {
+		return ErrNotConnected
+	}
+
+	_, err := b.client.NewChangeLeverageService().
+		Symbol(nativeSymbol(b, symbol)).
+		Leverage(leverage).
+		Do(ctx)
+	return binanceError(err)
+}
+
+// SetMarginMode switches a symbol between cross and isolated margin
+func (b *BinanceClient) SetMarginMode(ctx context.Context, symbol string, mode MarginMode) error {
+	if !b.connected {
+		return ErrNotConnected
+	}
+
+	marginType := futures.MarginTypeCrossed
+	if mode == IsolatedMargin {
+		marginType = futures.MarginTypeIsolated
+	}
+	err := b.client.NewChangeMarginTypeService().
+		Symbol(nativeSymbol(b, symbol)).
+		MarginType(marginType).
+		Do(ctx)
+	return ignoreAPICode(err, binanceNoMarginTypeChange)
+}
+
+// SetPositionMode switches the account between one-way and hedge mode
+func (b *BinanceClient) SetPositionMode(ctx context.Context, mode PositionMode) error {
+	if !b.connected {
+		return ErrNotConnected
+	}
+
+	err := b.client.NewChangePositionModeService().
+		DualSide(mode == HedgeMode).
+		Do(ctx)
+	return ignoreAPICode(err, binanceNoPositionModeChange)
+}
+
+// GetPositions returns all non-empty futures positions
+func (b *BinanceClient) GetPositions(ctx context.Context) ([]Position, error) {
+	if !b.connected {
+		return nil, ErrNotConnected
+	}
+
+	risks, err := b.client.NewGetPositionRiskService().Do(ctx)
+	if err != nil {
+		return nil, binanceError(err)
+	}
+
+	var positions []Position
+	for _, r := range risks {
+		size, _ := strconv.ParseFloat(r.PositionAmt, 64)
+		if size == 0 {
+			continue
+		}
+
+		p := Position{Symbol: r.Symbol, Size: size}
+		if s, err := b.FromExchange(r.Symbol); err == nil {
+			p.Symbol = s.String()
+		}
+		switch futures.PositionSideType(r.PositionSide) {
+		case futures.PositionSideTypeLong:
+			p.Side = order.PositionLong
+		case futures.PositionSideTypeShort:
+			p.Side = order.PositionShort
+		}
+		p.EntryPrice, _ = strconv.ParseFloat(r.EntryPrice, 64)
+		p.MarkPrice, _ = strconv.ParseFloat(r.MarkPrice, 64)
+		p.Notional, _ = strconv.ParseFloat(r.Notional, 64)
+		p.UnrealizedPnL, _ = strconv.ParseFloat(r.UnRealizedProfit, 64)
+		p.LiquidationPrice, _ = strconv.ParseFloat(r.LiquidationPrice, 64)
+		p.Leverage, _ = strconv.Atoi(r.Leverage)
+
+		if strings.EqualFold(r.MarginType, string(futures.MarginTypeIsolated)) {
+			p.MarginMode = IsolatedMargin
+			p.Margin, _ = strconv.ParseFloat(r.IsolatedMargin, 64)
+		} else if p.Leverage > 0 {
+			p.MarginMode = CrossMargin
+			p.Margin = math.Abs(p.Notional) / float64(p.Leverage)
+		}
+		positions = append(positions, p)
+	}
+	return positions, nil
+}
+
+// GetFundingInfo returns the mark price and next funding of a perpetual
+func (b *BinanceClient) GetFundingInfo(ctx context.Context, symbol string) (*FundingInfo, error) {
+	if !b.connected {
+		return nil, ErrNotConnected
+	}
+
+	res, err := b.client.NewPremiumIndexService().Symbol(nativeSymbol(b, symbol)).Do(ctx)
+	if err != nil {
+		return nil, binanceError(err)
+	}
+	if len(res) == 0 {
+		return nil, fmt.Errorf("binance: no premium index for %s", symbol)
+	}
+
+	info := &FundingInfo{
+		Symbol:          symbol,
+		NextFundingTime: time.UnixMilli(res[0].NextFundingTime),
+	}
+	info.MarkPrice, _ = strconv.ParseFloat(res[0].MarkPrice, 64)
+	info.Rate, _ = strconv.ParseFloat(res[0].LastFundingRate, 64)
+	return info, nil
+}
+
+// GetFundingHistory returns up to 1000 funding rates between start and end
+func (b *BinanceClient) GetFundingHistory(ctx context.Context, symbol string, start, end time.Time) ([]FundingRate, error) {
+	if !b.connected {
+		return nil, ErrNotConnected
+	}
+
+	svc := b.client.NewFundingRateService().Symbol(nativeSymbol(b, symbol)).Limit(1000)
+	if !start.IsZero() {
+		svc = svc.StartTime(start.UnixMilli())
+	}
+	if !end.IsZero() {
+		svc = svc.EndTime(end.UnixMilli())
+	}
+
+	res, err := svc.Do(ctx)
+	if err != nil {
+		return nil, binanceError(err)
+	}
+
+	rates := make([]FundingRate, 0, len(res))
+	for _, r := range res {
+		rate, _ := strconv.ParseFloat(r.FundingRate, 64)
+		rates = append(rates, FundingRate{
+			Symbol: symbol,
+			Rate:   rate,
+			Time:   time.UnixMilli(r.FundingTime),
+		})
+	}
+	return rates, nil
+}
+
+// Binance rejects requests that would not change anything
+const (
+	binanceNoMarginTypeChange   = -4046
+	binanceNoPositionModeChange = -4059
+)
+
+// ignoreAPICode treats a specific Binance error code as success
+func ignoreAPICode(err error, code int64) error {
+	var apiErr *common.APIError
+	if errors.As(err, &apiErr) && apiErr.Code == code {
+		return nil
+	}
+	return binanceError(err)
+}
+
+// binanceErrorKinds maps Binance error codes onto the common errors
+var binanceErrorKinds = map[int64]error{
+	-1006: ErrUnknownOutcome,    // unexpected response from the message bus
+	-1007: ErrUnknownOutcome,    // timeout waiting for the backend
+	-1016: ErrMaintenance,       // service no longer available
+	-1013: ErrInvalidOrder,      // filter failure
+	-1102: ErrInvalidOrder,      // mandatory parameter missing
+	-1111: ErrInvalidOrder,      // precision over the maximum
+	-1116: ErrInvalidOrder,      // invalid order type
+	-1117: ErrInvalidOrder,      // invalid side
+	-2011: ErrOrderNotFound,     // cancel of an unknown order
+	-2013: ErrOrderNotFound,     // order does not exist
+	-2018: ErrInsufficientFunds, // balance insufficient
+	-2019: ErrInsufficientFunds, // margin insufficient
+	-2021: ErrInvalidOrder,      // order would immediately trigger
+	-2022: ErrInvalidOrder,      // reduce-only order rejected
+	-4003: ErrInvalidOrder,      // quantity less than or equal to zero
+	-4014: ErrInvalidOrder,      // price not a multiple of the tick size
+	-4131: ErrInvalidOrder,      // outside the percent price filter
+	-4164: ErrInvalidOrder,      // notional below the minimum
+	-5022: ErrInvalidOrder,      // post-only order would take
+	-1003: ErrRateLimited,       // request weight exhausted
+}
+
+// binanceWalletErrorKinds maps the wallet (SAPI) error codes, which reuse
+// the -4xxx range with meanings of their own
+var binanceWalletErrorKinds = map[int64]error{
+	-1003: ErrRateLimited,       // request weight exhausted
+	-4005: ErrRateLimited,       // too many new requests
+	-4007: ErrInvalidTransfer,   // address validation failed
+	-4008: ErrInvalidTransfer,   // address tag validation failed
+	-4014: ErrInvalidTransfer,   // withdrawals blocked shortly after login
+	-4015: ErrInvalidTransfer,   // withdrawals limited on the account
+	-4016: ErrInvalidTransfer,   // withdrawals blocked after a password change
+	-4017: ErrInvalidTransfer,   // withdrawals blocked after 2FA was removed
+	-4018: ErrInvalidTransfer,   // asset not supported
+	-4019: ErrInvalidTransfer,   // asset closed for withdrawal
+	-4021: ErrInvalidTransfer,   // amount not a multiple of the step
+	-4022: ErrInvalidTransfer,   // amount below the minimum
+	-4023: ErrInvalidTransfer,   // over the 24 hour withdrawal limit
+	-4024: ErrInsufficientFunds, // asset not held
+	-4025: ErrInsufficientFunds, // held amount below zero
+	-4026: ErrInsufficientFunds, // balance insufficient
+	-4028: ErrInvalidTransfer,   // amount does not cover the fee
+	-4029: ErrTransferNotFound,  // withdrawal record does not exist
+}
+
+// binanceError converts a Binance futures API error into a VenueError.
+// Errors without a Binance error body come from the gateway, which may
+// already have forwarded the request.
+func binanceError(err error) error {
+	return binanceAPIError(err, binanceErrorKinds)
+}
+
+// binanceWalletError converts a Binance wallet API error
+func binanceWalletError(err error) error {
+	return binanceAPIError(err, binanceWalletErrorKinds)
+}
+
+func binanceAPIError(err error, kinds map[int64]error) error {
+	var apiErr *common.APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	if apiErr.Code == 0 && apiErr.Message == "" {
+		return fmt.Errorf("%w: binance: empty error response", ErrUnknownOutcome)
+	}
+	if kinds[apiErr.Code] == ErrRateLimited {
+		// Weight limits reset every minute
+		return &RateLimitError{Exchange: "binance", Class: ClassRequests, RetryAfter: time.Minute, StatusCode: http.StatusTooManyRequests}
+	}
+	return &VenueError{
+		Exchange: "binance",
+		Code:     strconv.FormatInt(apiErr.Code, 10),
+		Message:  apiErr.Message,
+		Err:      kinds[apiErr.Code],
+	}
+}
+
+// GetBalance returns the available balance of an asset on Binance
+func (b *BinanceClient) GetBalance(currency string) (float64, error) {
+	balance, err := b.GetAssetBalance(currency)
+	if err != nil {
+		return 0, err
+	}
+	return balance.AvailableBalance, nil
+}
+
+// GetAssetBalance returns the wallet and available balance of an asset
+func (b *BinanceClient) GetAssetBalance(asset string) (*AssetBalance, error) {
+	if !b.connected {
+		return nil, ErrNotConnected
+	}
+
+	balances, err := b.client.NewGetBalanceService().Do(context.Background())
+	if err != nil {
+		return nil, binanceError(err)
+	}
+
+	for _, bal := range balances {
+		if bal.Asset != asset {
+			continue
+		}
+		wallet, _ := strconv.ParseFloat(bal.Balance, 64)
+		available, _ := strconv.ParseFloat(bal.AvailableBalance, 64)
+		crossWallet, _ := strconv.ParseFloat(bal.CrossWalletBalance, 64)
+		unrealized, _ := strconv.ParseFloat(bal.CrossUnPnl, 64)
+		maxWithdraw, _ := strconv.ParseFloat(bal.MaxWithdrawAmount, 64)
+		return &AssetBalance{
+			Asset:              bal.Asset,
+			WalletBalance:      wallet,
+			AvailableBalance:   available,
+			CrossWalletBalance: crossWallet,
+			UnrealizedPnL:      unrealized,
+			MaxWithdrawAmount:  maxWithdraw,
+		}, nil
+	}
+	return &AssetBalance{Asset: asset}, nil
+}
+
+// GetBalances returns the wallet balance of every futures asset. Funds
+// that are not available for new orders, such as position margin, are
+// reported as locked.
+func (b *BinanceClient) GetBalances(ctx context.Context) ([]Balance, error) {
+	if !b.connected {
+		return nil, ErrNotConnected
+	}
+
+	res, err := b.client.NewGetBalanceService().Do(ctx)
+	if err != nil {
+		return nil, binanceError(err)
+	}
+
+	now := time.Now()
+	var balances []Balance
+	for _, bal := range res {
+		wallet, _ := strconv.ParseFloat(bal.Balance, 64)
+		available, _ := strconv.ParseFloat(bal.AvailableBalance, 64)
+		if wallet == 0 && available == 0 {
+			continue
+		}
+		balances = append(balances, Balance{
+			Asset:     canonicalAsset(bal.Asset),
+			Free:      available,
+			Locked:    math.Max(0, wallet-available),
+			Total:     wallet,
+			Timestamp: now,
+		})
+	}
+	return balances, nil
+}
+
+// GetFees returns the account's commission rates for a futures symbol
+func (b *BinanceClient) GetFees(ctx context.Context, symbol string) (*FeeSchedule, error) {
+	if !b.connected {
+		return nil, ErrNotConnected
+	}
+
+	res, err := b.client.NewCommissionRateService().Symbol(nativeSymbol(b, symbol)).Do(ctx)
+	if err != nil {
+		return nil, binanceError(err)
+	}
+	maker, _ := strconv.ParseFloat(res.MakerCommissionRate, 64)
+	taker, _ := strconv.ParseFloat(res.TakerCommissionRate, 64)
+	return &FeeSchedule{Maker: maker, Taker: taker}, nil
+}
+
+// GetDepositAddress returns the spot wallet deposit address of an asset.
+// Deposits land in the spot wallet and must be moved to futures before
+// they can be traded.
+func (b *BinanceClient) GetDepositAddress(ctx context.Context, asset, network string) (*DepositAddress, error) {
+	if !b.connected {
+		return nil, ErrNotConnected
+	}
+
+	svc := b.wallet.NewGetDepositAddressService().Coin(asset)
+	if network != "" {
+		svc = svc.Network(network)
+	}
+	res, err := svc.Do(ctx)
+	if err != nil {
+		return nil, binanceWalletError(err)
+	}
+	return &DepositAddress{
+		Asset:   canonicalAsset(res.Coin),
+		Network: network,
+		Address: res.Address,
+		Tag:     res.Tag,
+	}, nil
+}
+
+// Withdraw moves the amount from the futures wallet to spot and withdraws
+// it from there
+func (b *BinanceClient) Withdraw(ctx context.Context, w Withdrawal) (string, error) {
+	if !b.connected {
+		return "", ErrNotConnected
+	}
+
+	amount := strconv.FormatFloat(w.Amount, 'f', -1, 64)
+	_, err := b.wallet.NewFuturesTransferService().
+		Asset(w.Asset).
+		Amount(amount).
+		Type(binance.FuturesTransferTypeToMain).
+		Do(ctx)
+	if err != nil {
+		return "", fmt.Errorf("binance: error moving funds to spot wallet: %w", binanceWalletError(err))
+	}
+
+	svc := b.wallet.NewCreateWithdrawService().
+		Coin(w.Asset).
+		Address(w.Address).
+		Amount(amount)
+	if w.Network != "" {
+		svc = svc.Network(w.Network)
+	}
+	if w.Tag != "" {
+		svc = svc.AddressTag(w.Tag)
+	}
+	res, err := svc.Do(ctx)
+	if err != nil {
+		// Put the funds back where they can be traded. The context may be
+		// what failed the withdrawal, so the move back does not depend on it.
+		if _, moveErr := b.wallet.NewFuturesTransferService().
+			Asset(w.Asset).
+			Amount(amount).
+			Type(binance.FuturesTransferTypeToFutures).
+			Do(context.WithoutCancel(ctx)); moveErr != nil {
+			log.Printf("Binance: error moving %s %s back to futures after failed withdrawal: %v", amount, w.Asset, moveErr)
+		}
+		return "", binanceWalletError(err)
+	}
+	return res.ID, nil
+}
+
+// SweepDeposit moves a deposit credited to the spot wallet into futures
+func (b *BinanceClient) SweepDeposit(ctx context.Context, asset string, amount float64) error {
+	if !b.connected {
+		return ErrNotConnected
+	}
+
+	_, err := b.wallet.NewFuturesTransferService().
+		Asset(asset).
+		Amount(strconv.FormatFloat(amount, 'f', -1, 64)).
+		Type(binance.FuturesTransferTypeToFutures).
+		Do(ctx)
+	if err != nil {
+		return fmt.Errorf("binance: error moving deposit to futures wallet: %w", binanceWalletError(err))
+	}
+	return nil
+}
+
+// GetWithdrawal returns a withdrawal from the last 90 days of history
+func (b *BinanceClient) GetWithdrawal(ctx context.Context, asset, id string) (*Transfer, error) {
+	if !b.connected {
+		return nil, ErrNotConnected
+	}
+
+	res, err := b.wallet.NewListWithdrawsService().
+		Coin(asset).
+		StartTime(time.Now().Add(-90 * 24 * time.Hour).UnixMilli()).
+		Do(ctx)
+	if err != nil {
+		return nil, binanceWalletError(err)
+	}
+
+	for _, w := range res {
+		if w.ID != id {
+			continue
+		}
+		amount, _ := strconv.ParseFloat(w.Amount, 64)
+		fee, _ := strconv.ParseFloat(w.TransactionFee, 64)
+		applied, _ := time.Parse("2006-01-02 15:04:05", w.ApplyTime)
+		return &Transfer{
+			ID:        w.ID,
+			Asset:     canonicalAsset(w.Coin),
+			Network:   w.Network,
+			Address:   w.Address,
+			Amount:    amount,
+			Fee:       fee,
+			TxID:      w.TxID,
+			Status:    binanceWithdrawStatus(w.Status),
+			Timestamp: applied,
+		}, nil
+	}
+	return nil, ErrTransferNotFound
+}
+
+// GetDeposits returns spot wallet deposits of an asset since the given time
+func (b *BinanceClient) GetDeposits(ctx context.Context, asset string, since time.Time) ([]Transfer, error) {
+	if !b.connected {
+		return nil, ErrNotConnected
+	}
+
+	res, err := b.wallet.NewListDepositsService().
+		Coin(asset).
+		StartTime(since.UnixMilli()).
+		Do(ctx)
+	if err != nil {
+		return nil, binanceWalletError(err)
+	}
+
+	deposits := make([]Transfer, 0, len(res))
+	for _, d := range res {
+		amount, _ := strconv.ParseFloat(d.Amount, 64)
+		deposits = append(deposits, Transfer{
+			ID:        d.TxID,
+			Asset:     canonicalAsset(d.Coin),
+			Network:   d.Network,
+			Address:   d.Address,
+			Amount:    amount,
+			TxID:      d.TxID,
+			Status:    binanceDepositStatus(d.Status),
+			Timestamp: time.UnixMilli(d.InsertTime),
+		})
+	}
+	return deposits, nil
+}
+
+// binanceWithdrawStatus maps the numeric SAPI withdrawal status
+func binanceWithdrawStatus(status int) TransferStatus {
+	switch status {
+	case 0, 2:
+		return TransferPending // email sent, awaiting approval
+	case 4:
+		return TransferProcessing
+	case 6:
+		return TransferCompleted
+	case 1:
+		return TransferCancelled
+	default:
+		return TransferFailed // rejected, failure
+	}
+}
+
+// binanceDepositStatus maps the numeric SAPI deposit status
+func binanceDepositStatus(status int) TransferStatus {
+	switch status {
+	case 1, 6:
+		return TransferCompleted // credited
+	case 7:
+		return TransferFailed // wrong deposit
+	default:
+		return TransferProcessing
+	}
+}
+
+// GetInstruments loads trading rules for all futures symbols from exchangeInfo
+func (b *BinanceClient) GetInstruments(ctx context.Context) ([]Instrument, error) {
+	info, err := b.client.NewExchangeInfoService().Do(ctx)
+	if err != nil {
+		return nil, binanceError(err)
+	}
+
+	instruments := make([]Instrument, 0, len(info.Symbols))
+	for i := range info.Symbols {
+		sym := &info.Symbols[i]
+		inst := Instrument{
+			Symbol:     sym.Symbol,
+			BaseAsset:  sym.BaseAsset,
+			QuoteAsset: sym.QuoteAsset,
+		}
+		if f := sym.PriceFilter(); f != nil {
+			inst.TickSize, _ = strconv.ParseFloat(f.TickSize, 64)
+		}
+		if f := sym.LotSizeFilter(); f != nil {
+			inst.StepSize, _ = strconv.ParseFloat(f.StepSize, 64)
+			inst.MinQuantity, _ = strconv.ParseFloat(f.MinQuantity, 64)
+			inst.MaxQuantity, _ = strconv.ParseFloat(f.MaxQuantity, 64)
+		}
+		if f := sym.MinNotionalFilter(); f != nil {
+			inst.MinNotional, _ = strconv.ParseFloat(f.Notional, 64)
+		}
+		instruments = append(instruments, inst)
+	}
+	return instruments, nil
+}
+
+// binanceKlineIntervals maps the supported candle sizes onto interval names
+var binanceKlineIntervals = map[time.Duration]string{
+	time.Minute:      "1m",
+	3 * time.Minute:  "3m",
+	5 * time.Minute:  "5m",
+	15 * time.Minute: "15m",
+	30 * time.Minute: "30m",
+	time.Hour:        "1h",
+	2 * time.Hour:    "2h",
+	4 * time.Hour:    "4h",
+	6 * time.Hour:    "6h",
+	8 * time.Hour:    "8h",
+	12 * time.Hour:   "12h",
+	24 * time.Hour:   "1d",
+}
+
+// GetKlines returns up to 1500 klines starting at start
+func (b *BinanceClient) GetKlines(ctx context.Context, symbol string, interval time.Duration, start, end time.Time) ([]Kline, time.Time, error) {
+	name, ok := binanceKlineIntervals[interval]
+	if !ok {
+		return nil, start, fmt.Errorf("%w: binance has no %v klines", ErrNotSupported, interval)
+	}
+
+	const limit = 1500
+	res, err := b.client.NewKlinesService().
+		Symbol(nativeSymbol(b, symbol)).
+		Interval(name).
+		StartTime(start.UnixMilli()).
+		EndTime(end.UnixMilli() - 1).
+		Limit(limit).
+		Do(ctx)
+	if err != nil {
+		return nil, start, binanceError(err)
+	}
+
+	klines := make([]Kline, 0, len(res))
+	for _, k := range res {
+		kline := Kline{
+			Symbol:   symbol,
+			Interval: interval,
+			OpenTime: time.UnixMilli(k.OpenTime),
+			Trades:   k.TradeNum,
+		}
+		kline.Open, _ = strconv.ParseFloat(k.Open, 64)
+		kline.High, _ = strconv.ParseFloat(k.High, 64)
+		kline.Low, _ = strconv.ParseFloat(k.Low, 64)
+		kline.Close, _ = strconv.ParseFloat(k.Close, 64)
+		kline.Volume, _ = strconv.ParseFloat(k.Volume, 64)
+		klines = append(klines, kline)
+	}
+
+	if len(klines) < limit {
+		return klines, end, nil
+	}
+	return klines, klines[len(klines)-1].OpenTime.Add(interval), nil
+}
+
+// GetHistoricalTrades returns up to 1000 aggregate trades starting at
+// start. Binance only serves an hour of aggregate trades per request.
+func (b *BinanceClient) GetHistoricalTrades(ctx context.Context, symbol string, start, end time.Time) ([]HistoricalTrade, time.Time, error) {
+	windowEnd := start.Add(time.Hour)
+	if windowEnd.After(end) {
+		windowEnd = end
+	}
+
+	const limit = 1000
+	res, err := b.client.NewAggTradesService().
+		Symbol(nativeSymbol(b, symbol)).
+		StartTime(start.UnixMilli()).
+		EndTime(windowEnd.UnixMilli() - 1).
+		Limit(limit).
+		Do(ctx)
+	if err != nil {
+		return nil, start, binanceError(err)
+	}
+	if len(res) < limit {
+		return binanceHistoricalTrades(symbol, res), windowEnd, nil
+	}
+
+	// A full page may end part way through a millisecond; leave that
+	// millisecond to the next page unless it fills the whole page
+	last := res[len(res)-1].Timestamp
+	cut := len(res)
+	for cut > 0 && res[cut-1].Timestamp == last {
+		cut--
+	}
+	if cut == 0 {
+		return binanceHistoricalTrades(symbol, res), time.UnixMilli(last + 1), nil
+	}
+	return binanceHistoricalTrades(symbol, res[:cut]), time.UnixMilli(last), nil
 }
 
-// StreamTrades opens a real-time trade stream for a symbol
+func binanceHistoricalTrades(symbol string, res []*futures.AggTrade) []HistoricalTrade {
+	trades := make([]HistoricalTrade, 0, len(res))
+	for _, t := range res {
+		trade := HistoricalTrade{
+			ID:        strconv.FormatInt(t.AggTradeID, 10),
+			Symbol:    symbol,
+			Side:      order.Buy,
+			Timestamp: time.UnixMilli(t.Timestamp),
+		}
+		if t.IsBuyerMaker {
+			trade.Side = order.Sell
+		}
+		trade.Price, _ = strconv.ParseFloat(t.Price, 64)
+		trade.Quantity, _ = strconv.ParseFloat(t.Quantity, 64)
+		trades = append(trades, trade)
+	}
+	return trades
+}
+
+// ToExchange converts a canonical symbol into a Binance symbol (BTCUSDT). The
+// futures client only trades perpetuals, so spot symbols map onto them too.
+func (b *BinanceClient) ToExchange(s Symbol) string {
+	return s.Base + s.Quote
+}
+
+// FromExchange converts a Binance futures symbol into a canonical symbol.
+// Orders on this venue are keyed BASE/QUOTE, so reports and positions use
+// the same form rather than the :PERP suffix.
+func (b *BinanceClient) FromExchange(native string) (Symbol, error) {
+	return ParseSymbol(native)
+}
+
+// TrackOrder registers the canonical symbol of an order placed in a
+// previous session, since the futures API needs it to cancel or query the
+// order
+func (b *BinanceClient) TrackOrder(orderID, symbol string) {
+	b.trackOrder(orderID, nativeSymbol(b, symbol))
+}
+
+func (b *BinanceClient) trackOrder(orderID, symbol string) {
+	b.orderMutex.Lock()
+	defer b.orderMutex.Unlock()
+	b.orders[orderID] = symbol
+}
+
+// untrackOrder forgets an order that reached a terminal state
+func (b *BinanceClient) untrackOrder(orderID string) {
+	b.orderMutex.Lock()
+	defer b.orderMutex.Unlock()
+	delete(b.orders, orderID)
+}
+
+func (b *BinanceClient) lookupOrder(orderID string) (string, int64, error) {
+	id, err := strconv.ParseInt(orderID, 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("binance: invalid order ID %q: %w", orderID, err)
+	}
+
+	b.orderMutex.RLock()
+	symbol, ok := b.orders[orderID]
+	b.orderMutex.RUnlock()
+	if !ok {
+		return "", 0, fmt.Errorf("%w: binance: no symbol known for order %s", ErrOrderNotFound, orderID)
+	}
+	return symbol, id, nil
+}
+
+// binanceOrderStatus maps a futures order status onto order.Status
+func binanceOrderStatus(status futures.OrderStatusType) order.Status {
+	switch status {
+	case futures.OrderStatusTypeNew:
+		return order.SentToExchange
+	case futures.OrderStatusTypePartiallyFilled:
+		return order.PartiallyFilled
+	case futures.OrderStatusTypeFilled:
+		return order.Filled
+	case futures.OrderStatusTypeCanceled, futures.OrderStatusTypeExpired:
+		return order.Cancelled
+	case futures.OrderStatusTypeRejected:
+		return order.Rejected
+	default:
+		return order.Failed
+	}
+}
+
+// binanceTerminal reports whether an order can no longer change
+func binanceTerminal(status futures.OrderStatusType) bool {
+	switch status {
+	case futures.OrderStatusTypeFilled, futures.OrderStatusTypeCanceled,
+		futures.OrderStatusTypeExpired, futures.OrderStatusTypeRejected:
+		return true
+	}
+	return false
+}
+
+// StreamTrades opens a real-time trade stream for a symbol. The returned
+// channel stays open across reconnects and is closed once the context is
+// cancelled or the client disconnects.
 func (b *BinanceClient) StreamTrades(ctx context.Context, symbol string) (chan TradeEvent, error) {
 	if !b.connected {
 		return nil, ErrNotConnected
@@ -92,21 +1327,39 @@ func (b *BinanceClient) StreamTrades(ctx context.Context, symbol string) (chan T
 	b.streamMutex.Lock()
 	defer b.streamMutex.Unlock()
 
-	if ch, exists := b.streams[symbol]; exists {
-		return ch, nil
+	if s, exists := b.streams[symbol]; exists {
+		return s.events, nil
 	}
 
-	ch := make(chan TradeEvent, 100)
-	b.streams[symbol] = ch
+	ctx, cancel := context.WithCancel(ctx)
+	s := &binanceTradeStream{
+		events: make(chan TradeEvent, 100),
+		cancel: cancel,
+	}
+	done, stop, err := b.serveTrades(ctx, symbol, s.events)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	b.streams[symbol] = s
+	b.events.publish(ConnectionEvent{Stream: "trades", Symbol: symbol, State: Connected})
 
+	go b.maintainTrades(ctx, symbol, s, done, stop)
+	return s.events, nil
+}
+
+func (b *BinanceClient) serveTrades(ctx context.Context, symbol string, ch chan TradeEvent) (chan struct{}, chan struct{}, error) {
 	wsHandler := func(event *futures.WsAggTradeEvent) {
-		price, _ := event.Price.Float64()
-		qty, _ := event.Quantity.Float64()
-		ch <- TradeEvent{
-			Symbol:    event.Symbol,
+		price, _ := strconv.ParseFloat(event.Price, 64)
+		qty, _ := strconv.ParseFloat(event.Quantity, 64)
+		select {
+		case ch <- TradeEvent{
+			Symbol:    symbol,
 			Price:     price,
 			Quantity:  qty,
 			Timestamp: time.Unix(0, event.Time*int64(time.Millisecond)),
+		}:
+		case <-ctx.Done():
 		}
 	}
 
@@ -114,22 +1367,315 @@ func (b *BinanceClient) StreamTrades(ctx context.Context, symbol string) (chan T
 		log.Printf("Binance stream error: %v", err)
 	}
 
-	_, done, err := futures.WsAggTradeServe(symbol, wsHandler, errHandler)
+	return futures.WsAggTradeServe(nativeSymbol(b, symbol), wsHandler, errHandler)
+}
+
+// maintainTrades reopens a trade stream with backoff whenever its socket
+// drops, until the context is cancelled
+func (b *BinanceClient) maintainTrades(ctx context.Context, symbol string, s *binanceTradeStream, done, stop chan struct{}) {
+	defer func() {
+		b.streamMutex.Lock()
+		if b.streams[symbol] == s {
+			delete(b.streams, symbol)
+		}
+		b.streamMutex.Unlock()
+
+		// The handler can no longer send once the socket is done
+		close(s.events)
+		b.events.publish(ConnectionEvent{Stream: "trades", Symbol: symbol, State: Disconnected})
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			close(stop)
+			<-done
+			return
+		case <-done:
+		}
+		log.Printf("Binance trade stream for %s dropped, reconnecting", symbol)
+
+		var err error
+		for attempt := 0; ; attempt++ {
+			b.events.publish(ConnectionEvent{Stream: "trades", Symbol: symbol, State: Reconnecting, Attempt: attempt + 1, Err: err})
+			if !backoff(ctx, attempt) {
+				return
+			}
+			if done, stop, err = b.serveTrades(ctx, symbol, s.events); err == nil {
+				break
+			}
+			log.Printf("Binance trade stream reconnect for %s failed: %v", symbol, err)
+		}
+		b.events.publish(ConnectionEvent{Stream: "trades", Symbol: symbol, State: Connected})
+	}
+}
+
+// ConnectionEvents reports state changes of the trade, book and user data streams
+func (b *BinanceClient) ConnectionEvents(ctx context.Context) <-chan ConnectionEvent {
+	return b.events.subscribe(ctx)
+}
+
+// StreamOrderBook maintains a local order book from a REST snapshot and the
+// diff depth stream, following Binance's update ID sequencing rules
+func (b *BinanceClient) StreamOrderBook(ctx context.Context, symbol string, depth int) (*OrderBook, error) {
+	if !b.connected {
+		return nil, ErrNotConnected
+	}
+
+	book := NewOrderBook(symbol, depth)
+	native := nativeSymbol(b, symbol)
+
+	events := make(chan *futures.WsDepthEvent, 1000)
+	done, stop, err := b.serveDepth(native, events)
 	if err != nil {
 		return nil, err
 	}
 
+	b.events.publish(ConnectionEvent{Stream: "book", Symbol: symbol, State: Connected})
 	go func() {
-		<-done
-		b.streamMutex.Lock()
-		defer b.streamMutex.Unlock()
-		if ch, ok := b.streams[symbol]; ok {
-			close(ch)
-			delete(b.streams, symbol)
+		defer b.events.publish(ConnectionEvent{Stream: "book", Symbol: symbol, State: Disconnected})
+		for {
+			err := b.syncOrderBook(ctx, native, book, events, done)
+			close(stop)
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Binance order book for %s lost sync: %v", symbol, err)
+			book.Invalidate()
+
+			// Reopen the socket so buffered diffs from the old one are discarded
+			for attempt := 0; ; attempt++ {
+				b.events.publish(ConnectionEvent{Stream: "book", Symbol: symbol, State: Reconnecting, Attempt: attempt + 1, Err: err})
+				if !backoff(ctx, attempt) {
+					return
+				}
+				events = make(chan *futures.WsDepthEvent, 1000)
+				if done, stop, err = b.serveDepth(native, events); err == nil {
+					break
+				}
+				log.Printf("Binance depth stream reconnect for %s failed: %v", symbol, err)
+			}
+			b.events.publish(ConnectionEvent{Stream: "book", Symbol: symbol, State: Connected})
 		}
 	}()
 
-	return ch, nil
+	return book, nil
 }
 
-// Implement other required methods (CancelOrder, GetOrderStatus, GetBalance)...
\ No newline at end of file
+func (b *BinanceClient) serveDepth(symbol string, events chan *futures.WsDepthEvent) (chan struct{}, chan struct{}, error) {
+	handler := func(event *futures.WsDepthEvent) {
+		select {
+		case events <- event:
+		default:
+			// A dropped diff is a gap; the sequence check will force a resync
+			log.Printf("Binance depth buffer full for %s", symbol)
+		}
+	}
+	errHandler := func(err error) {
+		log.Printf("Binance depth stream error: %v", err)
+	}
+	return futures.WsDiffDepthServe(symbol, handler, errHandler)
+}
+
+// syncOrderBook loads a snapshot and applies buffered and live diffs until
+// the socket closes, the context ends or a sequence gap is detected
+func (b *BinanceClient) syncOrderBook(ctx context.Context, symbol string, book *OrderBook, events chan *futures.WsDepthEvent, done chan struct{}) error {
+	snapshot, err := b.client.NewDepthService().Symbol(symbol).Limit(1000).Do(ctx)
+	if err != nil {
+		return fmt.Errorf("snapshot: %w", err)
+	}
+	book.Reset(binanceLevels(snapshot.Bids), binanceLevels(snapshot.Asks))
+
+	lastID := snapshot.LastUpdateID
+	first := true
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-done:
+			return fmt.Errorf("depth stream closed")
+		case event := <-events:
+			// Drop diffs already contained in the snapshot
+			if event.LastUpdateID < snapshot.LastUpdateID {
+				continue
+			}
+			if first {
+				if event.FirstUpdateID > snapshot.LastUpdateID {
+					return fmt.Errorf("gap after snapshot %d: first diff starts at %d", snapshot.LastUpdateID, event.FirstUpdateID)
+				}
+				first = false
+			} else if event.PrevLastUpdateID != lastID {
+				return fmt.Errorf("gap: expected pu=%d, got %d", lastID, event.PrevLastUpdateID)
+			}
+
+			book.Update(binanceLevels(event.Bids), binanceLevels(event.Asks))
+			lastID = event.LastUpdateID
+		}
+	}
+}
+
+func binanceLevels(levels []futures.Bid) []PriceLevel {
+	out := make([]PriceLevel, 0, len(levels))
+	for i := range levels {
+		price, qty, err := levels[i].Parse()
+		if err != nil {
+			continue
+		}
+		out = append(out, PriceLevel{Price: price, Quantity: qty})
+	}
+	return out
+}
+
+// StreamExecutions subscribes to the futures user data stream. The listen
+// key is kept alive every 30 minutes and replaced whenever the socket drops
+// or Binance reports it expired.
+func (b *BinanceClient) StreamExecutions(ctx context.Context) (<-chan ExecutionReport, error) {
+	if !b.connected {
+		return nil, ErrNotConnected
+	}
+
+	out := make(chan ExecutionReport, 1000)
+	stream, err := b.openUserStream(ctx, out)
+	if err != nil {
+		return nil, err
+	}
+
+	b.events.publish(ConnectionEvent{Stream: "executions", State: Connected})
+	go func() {
+		defer close(out)
+		defer b.events.publish(ConnectionEvent{Stream: "executions", State: Disconnected})
+		for attempt := 0; ; {
+			stream.run(ctx, b.client)
+			if ctx.Err() != nil {
+				return
+			}
+			log.Println("Binance user data stream closed, reconnecting")
+
+			for {
+				b.events.publish(ConnectionEvent{Stream: "executions", State: Reconnecting, Attempt: attempt + 1, Err: err})
+				if !backoff(ctx, attempt) {
+					return
+				}
+				attempt++
+				if stream, err = b.openUserStream(ctx, out); err == nil {
+					attempt = 0
+					break
+				}
+				log.Printf("Binance user data stream reconnect failed: %v", err)
+			}
+			b.events.publish(ConnectionEvent{Stream: "executions", State: Connected})
+		}
+	}()
+
+	return out, nil
+}
+
+// binanceUserStream is one listen key and its websocket connection
+type binanceUserStream struct {
+	listenKey string
+	done      chan struct{}
+	stop      chan struct{}
+	expired   chan struct{}
+}
+
+func (b *BinanceClient) openUserStream(ctx context.Context, out chan<- ExecutionReport) (*binanceUserStream, error) {
+	listenKey, err := b.client.NewStartUserStreamService().Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stream := &binanceUserStream{
+		listenKey: listenKey,
+		expired:   make(chan struct{}, 1),
+	}
+
+	handler := func(event *futures.WsUserDataEvent) {
+		switch event.Event {
+		case futures.UserDataEventTypeListenKeyExpired:
+			select {
+			case stream.expired <- struct{}{}:
+			default:
+			}
+		case futures.UserDataEventTypeOrderTradeUpdate:
+			update := event.OrderTradeUpdate
+			if id := strconv.FormatInt(update.ID, 10); binanceTerminal(update.Status) {
+				b.untrackOrder(id)
+			} else {
+				b.trackOrder(id, update.Symbol)
+			}
+			select {
+			case out <- b.executionReport(update):
+			case <-ctx.Done():
+			}
+		}
+	}
+	errHandler := func(err error) {
+		log.Printf("Binance user data stream error: %v", err)
+	}
+
+	stream.done, stream.stop, err = futures.WsUserDataServe(listenKey, handler, errHandler)
+	if err != nil {
+		b.client.NewCloseUserStreamService().ListenKey(listenKey).Do(context.Background())
+		return nil, err
+	}
+	return stream, nil
+}
+
+// run keeps the listen key alive until the socket closes, the key expires
+// or the context ends, then tears the stream down
+func (s *binanceUserStream) run(ctx context.Context, client *futures.Client) {
+	ticker := time.NewTicker(30 * time.Minute)
+	defer ticker.Stop()
+
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-s.done:
+			break loop
+		case <-s.expired:
+			break loop
+		case <-ticker.C:
+			if err := client.NewKeepaliveUserStreamService().ListenKey(s.listenKey).Do(ctx); err != nil {
+				log.Printf("Binance listen key keepalive failed: %v", err)
+			}
+		}
+	}
+
+	// Wait for the reader to exit so no handler runs after the channel closes
+	close(s.stop)
+	<-s.done
+	client.NewCloseUserStreamService().ListenKey(s.listenKey).Do(context.Background())
+}
+
+func (b *BinanceClient) executionReport(u futures.WsOrderTradeUpdate) ExecutionReport {
+	side := order.Buy
+	if u.Side == futures.SideTypeSell {
+		side = order.Sell
+	}
+
+	symbol := u.Symbol
+	if s, err := b.FromExchange(u.Symbol); err == nil {
+		symbol = s.String()
+	}
+
+	report := ExecutionReport{
+		OrderID:       strconv.FormatInt(u.ID, 10),
+		ClientOrderID: u.ClientOrderID,
+		Symbol:        symbol,
+		Side:          side,
+		Status:        binanceOrderStatus(u.Status),
+		FeeCurrency:   u.CommissionAsset,
+		Timestamp:     time.Unix(0, u.TradeTime*int64(time.Millisecond)),
+	}
+	report.FilledQuantity, _ = strconv.ParseFloat(u.AccumulatedFilledQty, 64)
+	report.AveragePrice, _ = strconv.ParseFloat(u.AveragePrice, 64)
+	if u.ExecutionType == futures.OrderExecutionTypeTrade {
+		report.TradeID = strconv.FormatInt(u.TradeID, 10)
+		report.LastFillPrice, _ = strconv.ParseFloat(u.LastFilledPrice, 64)
+		report.LastFillQuantity, _ = strconv.ParseFloat(u.LastFilledQty, 64)
+		report.Fee, _ = strconv.ParseFloat(u.Commission, 64)
+	}
+	return report
+}
@@ -0,0 +1,282 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/trading-system/execution-engine/internal/order"
+)
+
+func TestBinancePlaceOrders(t *testing.T) {
+	requests := 0
+	b := newTestBinance(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Method != http.MethodPost || r.URL.Path != "/fapi/v1/batchOrders" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("X-MBX-APIKEY") != "key" || r.URL.Query().Get("signature") == "" {
+			t.Error("request not signed")
+		}
+
+		var entries []map[string]string
+		if err := json.Unmarshal([]byte(r.URL.Query().Get("batchOrders")), &entries); err != nil {
+			t.Error(err)
+		}
+		if len(entries) != 3 {
+			t.Errorf("got %d entries, want 3", len(entries))
+			return
+		}
+		if e := entries[0]; e["symbol"] != "BTCUSDT" || e["side"] != "BUY" || e["type"] != "LIMIT" || e["price"] != "50000" || e["timeInForce"] != "GTC" {
+			t.Errorf("unexpected limit entry %v", e)
+		}
+		if e := entries[1]; e["type"] != "MARKET" || e["side"] != "SELL" || e["reduceOnly"] != "true" || e["price"] != "" {
+			t.Errorf("unexpected market entry %v", e)
+		}
+
+		// The last entry is answered with neither an order nor an error
+		w.Write([]byte(`[
+			{"orderId":101,"symbol":"BTCUSDT","status":"NEW","clientOrderId":"a"},
+			{"code":-2019,"msg":"Margin is insufficient."}
+		]`))
+	})
+
+	orders := []*order.Order{
+		{Symbol: "BTC/USDT", Type: order.Limit, Side: order.Buy, Price: 50000, Quantity: 0.1},
+		{Symbol: "BTC/USDT", Type: order.Market, Side: order.Sell, Quantity: 0.1, ReduceOnly: true},
+		{Symbol: "ETH/USDT", Type: order.Market, Side: order.Buy, Quantity: 1},
+	}
+	results := b.PlaceOrders(context.Background(), orders)
+
+	if results[0].ID != "101" || results[0].Err != nil {
+		t.Errorf("result 0 = %+v, want order 101", results[0])
+	}
+	var venueErr *VenueError
+	if !errors.Is(results[1].Err, ErrInsufficientFunds) || !errors.As(results[1].Err, &venueErr) || venueErr.Code != "-2019" {
+		t.Errorf("result 1 err = %v, want -2019 insufficient funds", results[1].Err)
+	}
+	if !errors.Is(results[2].Err, ErrUnknownOutcome) {
+		t.Errorf("result 2 err = %v, want ErrUnknownOutcome", results[2].Err)
+	}
+	// Entries without a result are never resent
+	if requests != 1 {
+		t.Errorf("made %d requests, want 1", requests)
+	}
+	if _, _, err := b.lookupOrder("101"); err != nil {
+		t.Errorf("placed order not tracked: %v", err)
+	}
+}
+
+func TestBinanceCancelAllAfter(t *testing.T) {
+	open := `[{"symbol":"ETHUSDT","orderId":7}]`
+	countdowns := make(map[string]string)
+	b := newTestBinance(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fapi/v1/openOrders":
+			if r.Method != http.MethodGet || r.URL.Query().Get("symbol") != "" {
+				t.Errorf("unexpected open orders request %s %s", r.Method, r.URL)
+			}
+			w.Write([]byte(open))
+		case "/fapi/v1/countdownCancelAll":
+			q := r.URL.Query()
+			countdowns[q.Get("symbol")] = q.Get("countdownTime")
+			w.Write([]byte(`{"symbol":"` + q.Get("symbol") + `","countdownTime":"` + q.Get("countdownTime") + `"}`))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	})
+	b.trackOrder("1", "BTCUSDT")
+
+	ctx := context.Background()
+	if err := b.CancelAllAfter(ctx, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	// Orders opened elsewhere on the account are covered too
+	if countdowns["BTCUSDT"] != "60000" || countdowns["ETHUSDT"] != "60000" || len(countdowns) != 2 {
+		t.Errorf("countdowns = %v, want BTCUSDT and ETHUSDT at 60000", countdowns)
+	}
+
+	// Armed symbols keep being renewed once their orders are gone
+	open = `[]`
+	b.untrackOrder("1")
+	countdowns = make(map[string]string)
+	if err := b.CancelAllAfter(ctx, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if len(countdowns) != 2 {
+		t.Errorf("countdowns = %v, want both symbols renewed", countdowns)
+	}
+
+	countdowns = make(map[string]string)
+	if err := b.CancelAllAfter(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if countdowns["BTCUSDT"] != "0" || countdowns["ETHUSDT"] != "0" {
+		t.Errorf("countdowns = %v, want both disarmed", countdowns)
+	}
+	countdowns = make(map[string]string)
+	if err := b.CancelAllAfter(ctx, 0); err != nil || len(countdowns) != 0 {
+		t.Errorf("disarmed again: %v, %v", countdowns, err)
+	}
+}
+
+func TestBinanceGetOrderStatusUntracked(t *testing.T) {
+	b := newTestBinance(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/fapi/v1/order" || q.Get("symbol") != "BTCUSDT" || q.Get("orderId") != "42" {
+			t.Errorf("unexpected request %s", r.URL)
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"orderId":42,"symbol":"BTCUSDT","status":"FILLED"}`))
+	})
+	m := NewManager(map[string]Interface{"binance": b})
+
+	// An order from a previous session is unknown until its symbol is given
+	if _, err := m.GetOrderStatus("binance", "42"); !errors.Is(err, ErrOrderNotFound) {
+		t.Fatalf("err = %v, want ErrOrderNotFound", err)
+	}
+
+	m.TrackOrder("binance", "42", "BTC/USDT")
+	status, err := m.GetOrderStatus("binance", "42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != order.Filled {
+		t.Errorf("status = %v, want Filled", status)
+	}
+}
+
+func TestBinanceSyncOrderBook(t *testing.T) {
+	level := func(price, qty string) []futures.Bid { return []futures.Bid{{Price: price, Quantity: qty}} }
+	tests := []struct {
+		name    string
+		events  []*futures.WsDepthEvent
+		wantErr string
+		bid     float64 // quantity at 100 once synced
+		ask     float64 // quantity at 101 once synced
+	}{
+		{
+			name: "buffered diffs bridge the snapshot",
+			events: []*futures.WsDepthEvent{
+				// Already in the snapshot, would remove the bid if applied
+				{FirstUpdateID: 90, LastUpdateID: 95, PrevLastUpdateID: 89, Bids: level("100", "0")},
+				{FirstUpdateID: 98, LastUpdateID: 102, PrevLastUpdateID: 95, Bids: level("100", "2")},
+				{FirstUpdateID: 103, LastUpdateID: 105, PrevLastUpdateID: 102, Asks: level("101", "3")},
+				// Ends the test with a resync
+				{FirstUpdateID: 110, LastUpdateID: 112, PrevLastUpdateID: 108},
+			},
+			wantErr: "gap: expected pu=105, got 108",
+			bid:     2,
+			ask:     3,
+		},
+		{
+			name: "first diff after the snapshot",
+			events: []*futures.WsDepthEvent{
+				{FirstUpdateID: 105, LastUpdateID: 107, PrevLastUpdateID: 104, Bids: level("100", "5")},
+			},
+			wantErr: "gap after snapshot 100: first diff starts at 105",
+			bid:     1,
+			ask:     1,
+		},
+		{
+			name: "stale diffs are dropped",
+			events: []*futures.WsDepthEvent{
+				{FirstUpdateID: 80, LastUpdateID: 85, PrevLastUpdateID: 79, Asks: level("101", "0")},
+				{FirstUpdateID: 86, LastUpdateID: 99, PrevLastUpdateID: 85, Asks: level("101", "0")},
+				{FirstUpdateID: 100, LastUpdateID: 101, PrevLastUpdateID: 99, Bids: level("100", "4")},
+				{FirstUpdateID: 102, LastUpdateID: 103, PrevLastUpdateID: 100},
+			},
+			wantErr: "gap: expected pu=101, got 100",
+			bid:     4,
+			ask:     1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBinance(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/fapi/v1/depth" {
+					t.Errorf("unexpected path %s", r.URL.Path)
+				}
+				w.Write([]byte(`{"lastUpdateId":100,"bids":[["100","1"]],"asks":[["101","1"]]}`))
+			})
+
+			// Diffs received while the snapshot loads wait in the buffer
+			events := make(chan *futures.WsDepthEvent, len(tt.events))
+			for _, ev := range tt.events {
+				events <- ev
+			}
+			book := NewOrderBook("BTC/USDT", 10)
+			err := b.syncOrderBook(context.Background(), "BTCUSDT", book, events, make(chan struct{}))
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("err = %v, want %s", err, tt.wantErr)
+			}
+
+			bid, _ := book.BestBid()
+			ask, _ := book.BestAsk()
+			if bid.Price != 100 || bid.Quantity != tt.bid {
+				t.Errorf("best bid = %+v, want %v @ 100", bid, tt.bid)
+			}
+			if ask.Price != 101 || ask.Quantity != tt.ask {
+				t.Errorf("best ask = %+v, want %v @ 101", ask, tt.ask)
+			}
+		})
+	}
+}
+
+func TestBinanceWithdrawReturnsFundsToFutures(t *testing.T) {
+	var moves []string
+	b := newTestBinance(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sapi/v1/futures/transfer":
+			moves = append(moves, r.FormValue("type")+":"+r.FormValue("amount"))
+			w.Write([]byte(`{"tranId":1}`))
+		case "/sapi/v1/capital/withdraw/apply":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":-4014,"msg":"Withdraw amount must be an integer multiple of the step."}`))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	})
+
+	// Wallet codes have their own meaning: -4014 is a withdrawal restriction
+	_, err := b.Withdraw(context.Background(), Withdrawal{Asset: "USDT", Address: "addr", Amount: 150.5})
+	if !errors.Is(err, ErrInvalidTransfer) {
+		t.Fatalf("err = %v, want ErrInvalidTransfer", err)
+	}
+	// Out to spot for the withdrawal, then back once it failed
+	if len(moves) != 2 || moves[0] != "2:150.5" || moves[1] != "1:150.5" {
+		t.Errorf("transfers = %v, want 2:150.5 then 1:150.5", moves)
+	}
+
+	moves = nil
+	if err := b.SweepDeposit(context.Background(), "USDT", 99); err != nil {
+		t.Fatal(err)
+	}
+	if len(moves) != 1 || moves[0] != "1:99" {
+		t.Errorf("transfers = %v, want 1:99", moves)
+	}
+}
+
+func TestBinanceRateLimitedHistory(t *testing.T) {
+	b := newTestBinance(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":-1003,"msg":"Too many requests."}`))
+	})
+
+	// Paginated history waits on rate limit errors rather than failing
+	_, _, err := b.GetKlines(context.Background(), "BTC/USDT", time.Minute, time.Now().Add(-time.Hour), time.Now())
+	var rateLimited *RateLimitError
+	if !errors.As(err, &rateLimited) || rateLimited.RetryAfter <= 0 {
+		t.Errorf("err = %v, want a RateLimitError", err)
+	}
+}
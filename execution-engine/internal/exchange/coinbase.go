@@ -0,0 +1,1136 @@
+package exchange
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/trading-system/execution-engine/internal/order"
+)
+
+const (
+	coinbaseRESTURL = "https://api.coinbase.com"
+	coinbaseWSURL   = "wss://advanced-trade-ws.coinbase.com"
+)
+
+// CoinbaseClient implements the exchange interface for Coinbase Advanced Trade
+type CoinbaseClient struct {
+	apiKey      string
+	apiSecret   string
+	signingKey  *ecdsa.PrivateKey
+	restURL     string
+	wsURL       string
+	httpClient  *http.Client
+	limiter     *RateLimiter
+	streams     map[string]*coinbaseTradeStream
+	streamMutex sync.Mutex
+	events      connectionEvents
+	connected   bool
+}
+
+// coinbaseTradeStream is a trade subscription that survives reconnects
+type coinbaseTradeStream struct {
+	events chan TradeEvent
+	cancel context.CancelFunc
+}
+
+// errCoinbaseSubscription is returned by readers when Coinbase rejects a
+// subscription, which resubscribing would not fix
+var errCoinbaseSubscription = errors.New("coinbase: subscription rejected")
+
+// NewCoinbaseClient creates a new Coinbase client
+func NewCoinbaseClient() *CoinbaseClient {
+	limiter := NewRateLimiter("coinbase", coinbaseRateLimits...)
+	return &CoinbaseClient{
+		restURL:    coinbaseRESTURL,
+		wsURL:      coinbaseWSURL,
+		httpClient: newRateLimitedClient(limiter, coinbaseRequestCosts, nil),
+		limiter:    limiter,
+		streams:    make(map[string]*coinbaseTradeStream),
+	}
+}
+
+// coinbaseRateLimits are the Advanced Trade limits of 30 private and 10
+// public requests per second
+var coinbaseRateLimits = []RateLimit{
+	{Class: ClassRequests, Limit: 30, Interval: time.Second},
+	{Class: ClassPublic, Limit: 10, Interval: time.Second},
+}
+
+func coinbaseRequestCosts(req *http.Request) []Cost {
+	if strings.HasPrefix(req.URL.Path, "/api/v3/brokerage/market/") {
+		return []Cost{{ClassPublic, 1}}
+	}
+	return []Cost{{ClassRequests, 1}}
+}
+
+// RateLimiter returns the limiter shared by all Coinbase REST calls
+func (c *CoinbaseClient) RateLimiter() *RateLimiter {
+	return c.limiter
+}
+
+// SetCredentials sets the API credentials. A PEM encoded EC private key
+// selects CDP JWT authentication, any other secret is used for legacy HMAC
+// signing.
+func (c *CoinbaseClient) SetCredentials(apiKey, apiSecret string) error {
+	c.apiKey = apiKey
+	c.apiSecret = apiSecret
+	c.signingKey = nil
+
+	secret := strings.ReplaceAll(apiSecret, `\n`, "\n")
+	if !strings.Contains(secret, "PRIVATE KEY") {
+		return nil
+	}
+
+	block, _ := pem.Decode([]byte(secret))
+	if block == nil {
+		return errors.New("coinbase: invalid PEM private key")
+	}
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		parsed, pkcs8Err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if pkcs8Err != nil {
+			return fmt.Errorf("coinbase: error parsing private key: %w", err)
+		}
+		ecKey, ok := parsed.(*ecdsa.PrivateKey)
+		if !ok {
+			return errors.New("coinbase: private key is not an EC key")
+		}
+		key = ecKey
+	}
+	c.signingKey = key
+	return nil
+}
+
+// SetEndpoints overrides the REST and websocket base URLs
+func (c *CoinbaseClient) SetEndpoints(restURL, wsURL string) {
+	c.restURL = strings.TrimRight(restURL, "/")
+	c.wsURL = wsURL
+}
+
+// coinbaseEndpoints are the Advanced Trade deployments. The sandbox serves
+// canned REST responses only, so streams stay on the public production
+// feed and private streams are unavailable there.
+var coinbaseEndpoints = map[Environment]Endpoints{
+	Production: {REST: coinbaseRESTURL, Websocket: coinbaseWSURL},
+	Sandbox:    {REST: "https://api-sandbox.coinbase.com", Websocket: coinbaseWSURL},
+}
+
+// Configure selects the deployment and sets the API credentials
+func (c *CoinbaseClient) Configure(cfg EnvironmentConfig) error {
+	endpoints, err := resolveEndpoints("coinbase", coinbaseEndpoints, cfg)
+	if err != nil {
+		return err
+	}
+	if err := c.SetCredentials(cfg.APIKey, cfg.APISecret); err != nil {
+		return err
+	}
+	c.SetEndpoints(endpoints.REST, endpoints.Websocket)
+	return nil
+}
+
+// Connect establishes connection to Coinbase
+func (c *CoinbaseClient) Connect() error {
+	// Test connectivity
+	var res struct {
+		ISO string `json:"iso"`
+	}
+	if err := c.request(context.Background(), http.MethodGet, "/api/v3/brokerage/time", nil, nil, &res); err != nil {
+		return err
+	}
+	c.connected = true
+	log.Println("Connected to Coinbase")
+	return nil
+}
+
+// Disconnect closes all connections
+func (c *CoinbaseClient) Disconnect() error {
+	c.streamMutex.Lock()
+	defer c.streamMutex.Unlock()
+
+	// Each stream closes its channel once its socket has shut down
+	for symbol, s := range c.streams {
+		s.cancel()
+		delete(c.streams, symbol)
+	}
+	c.connected = false
+	return nil
+}
+
+// PlaceOrder places an order on Coinbase
+func (c *CoinbaseClient) PlaceOrder(ctx context.Context, o *order.Order) (string, error) {
+	if !c.connected {
+		return "", ErrNotConnected
+	}
+	if err := checkSpotOrder(o); err != nil {
+		return "", err
+	}
+
+	side := "BUY"
+	if o.Side == order.Sell {
+		side = "SELL"
+	}
+
+	config := map[string]interface{}{}
+	if o.Type == order.Market {
+		config["market_market_ioc"] = map[string]string{
+			"base_size": o.QuantityString(),
+		}
+	} else {
+		config["limit_limit_gtc"] = map[string]interface{}{
+			"base_size":   o.QuantityString(),
+			"limit_price": o.PriceString(),
+			"post_only":   false,
+		}
+	}
+
+	clientOrderID := o.ID
+	if clientOrderID == "" {
+		clientOrderID = randomHex(16)
+	}
+
+	body := map[string]interface{}{
+		"client_order_id":     clientOrderID,
+		"product_id":          nativeSymbol(c, o.Symbol),
+		"side":                side,
+		"order_configuration": config,
+	}
+
+	var res struct {
+		Success         bool `json:"success"`
+		SuccessResponse struct {
+			OrderID string `json:"order_id"`
+		} `json:"success_response"`
+		ErrorResponse struct {
+			Error                 string `json:"error"`
+			Message               string `json:"message"`
+			PreviewFailureReason  string `json:"preview_failure_reason"`
+			NewOrderFailureReason string `json:"new_order_failure_reason"`
+		} `json:"error_response"`
+	}
+	if err := c.request(ctx, http.MethodPost, "/api/v3/brokerage/orders", nil, body, &res); err != nil {
+		return "", err
+	}
+	if !res.Success {
+		reason := res.ErrorResponse.NewOrderFailureReason
+		if reason == "" || reason == "UNKNOWN_FAILURE_REASON" {
+			reason = res.ErrorResponse.PreviewFailureReason
+		}
+		if reason == "" || reason == "UNKNOWN_PREVIEW_FAILURE_REASON" {
+			reason = res.ErrorResponse.Error
+		}
+		return "", coinbaseError(reason, "order rejected: "+res.ErrorResponse.Message)
+	}
+	return res.SuccessResponse.OrderID, nil
+}
+
+// CancelOrder cancels an open order on Coinbase
+func (c *CoinbaseClient) CancelOrder(orderID string) error {
+	if !c.connected {
+		return ErrNotConnected
+	}
+
+	body := map[string][]string{"order_ids": {orderID}}
+
+	var res struct {
+		Results []struct {
+			Success       bool   `json:"success"`
+			FailureReason string `json:"failure_reason"`
+			OrderID       string `json:"order_id"`
+		} `json:"results"`
+	}
+	if err := c.request(context.Background(), http.MethodPost, "/api/v3/brokerage/orders/batch_cancel", nil, body, &res); err != nil {
+		return err
+	}
+	for _, r := range res.Results {
+		if r.OrderID == orderID && !r.Success {
+			return coinbaseError(r.FailureReason, "cancel of "+orderID+" failed")
+		}
+	}
+	return nil
+}
+
+// ModifyOrder edits the price and size of a working GTC limit order in
+// place, keeping its order ID
+func (c *CoinbaseClient) ModifyOrder(ctx context.Context, o *order.Order, price, quantity float64) (string, error) {
+	if !c.connected {
+		return "", ErrNotConnected
+	}
+
+	body := map[string]string{
+		"order_id": o.ID,
+		"price":    strconv.FormatFloat(price, 'f', -1, 64),
+		"size":     strconv.FormatFloat(quantity, 'f', -1, 64),
+	}
+
+	var res struct {
+		Success bool `json:"success"`
+		Errors  []struct {
+			EditFailureReason    string `json:"edit_failure_reason"`
+			PreviewFailureReason string `json:"preview_failure_reason"`
+		} `json:"errors"`
+	}
+	if err := c.request(ctx, http.MethodPost, "/api/v3/brokerage/orders/edit", nil, body, &res); err != nil {
+		return "", err
+	}
+	if !res.Success {
+		var reason string
+		if len(res.Errors) > 0 {
+			reason = res.Errors[0].EditFailureReason + res.Errors[0].PreviewFailureReason
+		}
+		return "", coinbaseError(reason, "edit of "+o.ID+" rejected")
+	}
+	return o.ID, nil
+}
+
+// GetOrderStatus returns the current status of an order on Coinbase
+func (c *CoinbaseClient) GetOrderStatus(orderID string) (order.Status, error) {
+	if !c.connected {
+		return order.Failed, ErrNotConnected
+	}
+
+	var res struct {
+		Order coinbaseOrder `json:"order"`
+	}
+	path := "/api/v3/brokerage/orders/historical/" + url.PathEscape(orderID)
+	if err := c.request(context.Background(), http.MethodGet, path, nil, nil, &res); err != nil {
+		return order.Failed, err
+	}
+	return res.Order.status(), nil
+}
+
+// GetBalance returns the available balance of a currency on Coinbase
+func (c *CoinbaseClient) GetBalance(currency string) (float64, error) {
+	if !c.connected {
+		return 0, ErrNotConnected
+	}
+
+	accounts, err := c.accounts(context.Background())
+	if err != nil {
+		return 0, err
+	}
+
+	currency = strings.ToUpper(currency)
+	for _, acc := range accounts {
+		if acc.Currency == currency {
+			return strconv.ParseFloat(acc.AvailableBalance.Value, 64)
+		}
+	}
+	return 0, nil
+}
+
+// GetBalances returns every account with its available and held funds
+func (c *CoinbaseClient) GetBalances(ctx context.Context) ([]Balance, error) {
+	if !c.connected {
+		return nil, ErrNotConnected
+	}
+
+	accounts, err := c.accounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var balances []Balance
+	for _, acc := range accounts {
+		free, _ := strconv.ParseFloat(acc.AvailableBalance.Value, 64)
+		hold, _ := strconv.ParseFloat(acc.Hold.Value, 64)
+		if free == 0 && hold == 0 {
+			continue
+		}
+		balances = append(balances, Balance{
+			Asset:     canonicalAsset(acc.Currency),
+			Free:      free,
+			Locked:    hold,
+			Total:     free + hold,
+			Timestamp: now,
+		})
+	}
+	return balances, nil
+}
+
+// GetFees returns the account's fee tier. Coinbase rates depend on the
+// tier only, not the product.
+func (c *CoinbaseClient) GetFees(ctx context.Context, symbol string) (*FeeSchedule, error) {
+	if !c.connected {
+		return nil, ErrNotConnected
+	}
+
+	var res struct {
+		FeeTier struct {
+			MakerFeeRate string `json:"maker_fee_rate"`
+			TakerFeeRate string `json:"taker_fee_rate"`
+		} `json:"fee_tier"`
+	}
+	if err := c.request(ctx, http.MethodGet, "/api/v3/brokerage/transaction_summary", nil, nil, &res); err != nil {
+		return nil, err
+	}
+	maker, _ := strconv.ParseFloat(res.FeeTier.MakerFeeRate, 64)
+	taker, _ := strconv.ParseFloat(res.FeeTier.TakerFeeRate, 64)
+	return &FeeSchedule{Maker: maker, Taker: taker}, nil
+}
+
+// accounts pages through all brokerage accounts
+func (c *CoinbaseClient) accounts(ctx context.Context) ([]coinbaseAccount, error) {
+	var accounts []coinbaseAccount
+	cursor := ""
+	for {
+		query := url.Values{}
+		query.Set("limit", "250")
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+
+		var res struct {
+			Accounts []coinbaseAccount `json:"accounts"`
+			HasNext  bool              `json:"has_next"`
+			Cursor   string            `json:"cursor"`
+		}
+		if err := c.request(ctx, http.MethodGet, "/api/v3/brokerage/accounts", query, nil, &res); err != nil {
+			return nil, err
+		}
+
+		accounts = append(accounts, res.Accounts...)
+		if !res.HasNext || res.Cursor == "" {
+			return accounts, nil
+		}
+		cursor = res.Cursor
+	}
+}
+
+// StreamTrades opens a real-time trade stream for a symbol using the
+// market_trades channel, the Advanced Trade counterpart of the "matches" feed
+func (c *CoinbaseClient) StreamTrades(ctx context.Context, symbol string) (chan TradeEvent, error) {
+	if !c.connected {
+		return nil, ErrNotConnected
+	}
+
+	c.streamMutex.Lock()
+	defer c.streamMutex.Unlock()
+
+	if s, exists := c.streams[symbol]; exists {
+		return s.events, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	conn, err := c.subscribeTrades(ctx, symbol)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	s := &coinbaseTradeStream{
+		events: make(chan TradeEvent, 100),
+		cancel: cancel,
+	}
+	c.streams[symbol] = s
+	c.events.publish(ConnectionEvent{Stream: "trades", Symbol: symbol, State: Connected})
+
+	go c.maintainTrades(ctx, symbol, s, conn)
+	return s.events, nil
+}
+
+func (c *CoinbaseClient) subscribeTrades(ctx context.Context, symbol string) (*websocket.Conn, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, c.wsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	subscribe := map[string]interface{}{
+		"type":        "subscribe",
+		"product_ids": []string{nativeSymbol(c, symbol)},
+		"channel":     "market_trades",
+	}
+	if err := conn.WriteJSON(subscribe); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// maintainTrades reads a trade stream and resubscribes with backoff
+// whenever its socket drops, until the context is cancelled
+func (c *CoinbaseClient) maintainTrades(ctx context.Context, symbol string, s *coinbaseTradeStream, conn *websocket.Conn) {
+	defer func() {
+		c.streamMutex.Lock()
+		if c.streams[symbol] == s {
+			delete(c.streams, symbol)
+		}
+		c.streamMutex.Unlock()
+
+		close(s.events)
+		c.events.publish(ConnectionEvent{Stream: "trades", Symbol: symbol, State: Disconnected})
+	}()
+
+	for {
+		err := c.readTrades(ctx, symbol, conn, s.events)
+		conn.Close()
+		if ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, errCoinbaseSubscription) {
+			log.Printf("Coinbase trade stream for %s closed: %v", symbol, err)
+			return
+		}
+		log.Printf("Coinbase trade stream for %s dropped, reconnecting: %v", symbol, err)
+
+		for attempt := 0; ; attempt++ {
+			c.events.publish(ConnectionEvent{Stream: "trades", Symbol: symbol, State: Reconnecting, Attempt: attempt + 1, Err: err})
+			if !backoff(ctx, attempt) {
+				return
+			}
+			if conn, err = c.subscribeTrades(ctx, symbol); err == nil {
+				break
+			}
+			log.Printf("Coinbase trade stream reconnect for %s failed: %v", symbol, err)
+		}
+		c.events.publish(ConnectionEvent{Stream: "trades", Symbol: symbol, State: Connected})
+	}
+}
+
+// ConnectionEvents reports state changes of the trade streams
+func (c *CoinbaseClient) ConnectionEvents(ctx context.Context) <-chan ConnectionEvent {
+	return c.events.subscribe(ctx)
+}
+
+// GetInstruments loads trading rules for all spot products
+func (c *CoinbaseClient) GetInstruments(ctx context.Context) ([]Instrument, error) {
+	var res struct {
+		Products []struct {
+			ProductID      string `json:"product_id"`
+			BaseCurrency   string `json:"base_currency_id"`
+			QuoteCurrency  string `json:"quote_currency_id"`
+			PriceIncrement string `json:"price_increment"`
+			BaseIncrement  string `json:"base_increment"`
+			BaseMinSize    string `json:"base_min_size"`
+			BaseMaxSize    string `json:"base_max_size"`
+			QuoteMinSize   string `json:"quote_min_size"`
+		} `json:"products"`
+	}
+	query := url.Values{}
+	query.Set("product_type", "SPOT")
+	if err := c.request(ctx, http.MethodGet, "/api/v3/brokerage/market/products", query, nil, &res); err != nil {
+		return nil, err
+	}
+
+	instruments := make([]Instrument, 0, len(res.Products))
+	for _, p := range res.Products {
+		inst := Instrument{
+			Symbol:     p.ProductID,
+			BaseAsset:  p.BaseCurrency,
+			QuoteAsset: p.QuoteCurrency,
+		}
+		inst.TickSize, _ = strconv.ParseFloat(p.PriceIncrement, 64)
+		inst.StepSize, _ = strconv.ParseFloat(p.BaseIncrement, 64)
+		inst.MinQuantity, _ = strconv.ParseFloat(p.BaseMinSize, 64)
+		inst.MaxQuantity, _ = strconv.ParseFloat(p.BaseMaxSize, 64)
+		inst.MinNotional, _ = strconv.ParseFloat(p.QuoteMinSize, 64)
+		instruments = append(instruments, inst)
+	}
+	return instruments, nil
+}
+
+// coinbaseGranularities maps the supported candle sizes onto granularity names
+var coinbaseGranularities = map[time.Duration]string{
+	time.Minute:      "ONE_MINUTE",
+	5 * time.Minute:  "FIVE_MINUTE",
+	15 * time.Minute: "FIFTEEN_MINUTE",
+	30 * time.Minute: "THIRTY_MINUTE",
+	time.Hour:        "ONE_HOUR",
+	2 * time.Hour:    "TWO_HOUR",
+	6 * time.Hour:    "SIX_HOUR",
+	24 * time.Hour:   "ONE_DAY",
+}
+
+// GetKlines returns up to 350 candles starting at start, the most Coinbase
+// serves per request
+func (c *CoinbaseClient) GetKlines(ctx context.Context, symbol string, interval time.Duration, start, end time.Time) ([]Kline, time.Time, error) {
+	granularity, ok := coinbaseGranularities[interval]
+	if !ok {
+		return nil, start, fmt.Errorf("%w: coinbase has no %v candles", ErrNotSupported, interval)
+	}
+
+	windowEnd := start.Add(350 * interval)
+	if windowEnd.After(end) {
+		windowEnd = end
+	}
+	query := url.Values{
+		"start":       {strconv.FormatInt(start.Unix(), 10)},
+		"end":         {strconv.FormatInt(windowEnd.Unix()-1, 10)},
+		"granularity": {granularity},
+	}
+	var res struct {
+		Candles []struct {
+			Start  string `json:"start"`
+			Low    string `json:"low"`
+			High   string `json:"high"`
+			Open   string `json:"open"`
+			Close  string `json:"close"`
+			Volume string `json:"volume"`
+		} `json:"candles"`
+	}
+	path := "/api/v3/brokerage/market/products/" + nativeSymbol(c, symbol) + "/candles"
+	if err := c.request(ctx, http.MethodGet, path, query, nil, &res); err != nil {
+		return nil, start, err
+	}
+
+	klines := make([]Kline, 0, len(res.Candles))
+	for _, candle := range res.Candles {
+		secs, _ := strconv.ParseInt(candle.Start, 10, 64)
+		kline := Kline{Symbol: symbol, Interval: interval, OpenTime: time.Unix(secs, 0)}
+		kline.Open, _ = strconv.ParseFloat(candle.Open, 64)
+		kline.High, _ = strconv.ParseFloat(candle.High, 64)
+		kline.Low, _ = strconv.ParseFloat(candle.Low, 64)
+		kline.Close, _ = strconv.ParseFloat(candle.Close, 64)
+		kline.Volume, _ = strconv.ParseFloat(candle.Volume, 64)
+		klines = append(klines, kline)
+	}
+	// Candles come newest first
+	sort.Slice(klines, func(i, j int) bool { return klines[i].OpenTime.Before(klines[j].OpenTime) })
+	return klines, windowEnd, nil
+}
+
+// GetHistoricalTrades returns the trades in a window starting at start.
+// Coinbase returns the newest trades of a window first, so the window is
+// narrowed until it holds less than a full page.
+func (c *CoinbaseClient) GetHistoricalTrades(ctx context.Context, symbol string, start, end time.Time) ([]HistoricalTrade, time.Time, error) {
+	const limit = 1000
+	path := "/api/v3/brokerage/market/products/" + nativeSymbol(c, symbol) + "/ticker"
+
+	window := time.Hour
+	for {
+		windowEnd := start.Add(window)
+		if windowEnd.After(end) {
+			windowEnd = end
+		}
+		query := url.Values{
+			"limit": {strconv.Itoa(limit)},
+			"start": {strconv.FormatInt(start.Unix(), 10)},
+			"end":   {strconv.FormatInt(windowEnd.Unix(), 10)},
+		}
+		var res struct {
+			Trades []struct {
+				TradeID string    `json:"trade_id"`
+				Price   string    `json:"price"`
+				Size    string    `json:"size"`
+				Time    time.Time `json:"time"`
+				Side    string    `json:"side"`
+			} `json:"trades"`
+		}
+		if err := c.request(ctx, http.MethodGet, path, query, nil, &res); err != nil {
+			return nil, start, err
+		}
+		if len(res.Trades) >= limit && window > time.Second {
+			window /= 2
+			continue
+		}
+
+		trades := make([]HistoricalTrade, 0, len(res.Trades))
+		for _, t := range res.Trades {
+			if t.Time.Before(start) || !t.Time.Before(windowEnd) {
+				continue
+			}
+			trade := HistoricalTrade{ID: t.TradeID, Symbol: symbol, Side: order.Buy, Timestamp: t.Time}
+			if t.Side == "SELL" {
+				trade.Side = order.Sell
+			}
+			trade.Price, _ = strconv.ParseFloat(t.Price, 64)
+			trade.Quantity, _ = strconv.ParseFloat(t.Size, 64)
+			trades = append(trades, trade)
+		}
+		sort.Slice(trades, func(i, j int) bool { return trades[i].Timestamp.Before(trades[j].Timestamp) })
+		return trades, windowEnd, nil
+	}
+}
+
+// readTrades forwards trades from a socket until it fails or the context
+// is cancelled
+func (c *CoinbaseClient) readTrades(ctx context.Context, symbol string, conn *websocket.Conn, ch chan TradeEvent) error {
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		var msg coinbaseWSMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return err
+		}
+
+		switch msg.Channel {
+		case "market_trades":
+		case "":
+			if msg.Type == "error" {
+				return fmt.Errorf("%w: %s", errCoinbaseSubscription, msg.Message)
+			}
+			continue
+		default:
+			continue
+		}
+
+		for _, event := range msg.Events {
+			// The snapshot replays recent history, only forward live trades
+			if event.Type != "update" {
+				continue
+			}
+			for _, t := range event.Trades {
+				price, _ := strconv.ParseFloat(t.Price, 64)
+				qty, _ := strconv.ParseFloat(t.Size, 64)
+				select {
+				case ch <- TradeEvent{
+					Symbol:    symbol,
+					Price:     price,
+					Quantity:  qty,
+					Timestamp: t.Time,
+				}:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+	}
+}
+
+// StreamExecutions subscribes to the user channel. Coinbase reports
+// cumulative order state, so fills are derived from the change in filled
+// quantity, average price and fees between updates.
+func (c *CoinbaseClient) StreamExecutions(ctx context.Context) (<-chan ExecutionReport, error) {
+	if !c.connected {
+		return nil, ErrNotConnected
+	}
+	if c.signingKey == nil {
+		return nil, errors.New("coinbase: user channel requires a CDP API key")
+	}
+
+	conn, err := c.subscribeUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan ExecutionReport, 1000)
+	go func() {
+		defer close(out)
+		seen := make(map[string]coinbaseUserOrder)
+		for attempt := 0; ; {
+			err := c.readExecutions(ctx, conn, seen, out)
+			conn.Close()
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Coinbase user stream error: %v", err)
+
+			for {
+				if !backoff(ctx, attempt) {
+					return
+				}
+				attempt++
+				if conn, err = c.subscribeUser(ctx); err == nil {
+					attempt = 0
+					break
+				}
+				log.Printf("Coinbase user stream reconnect failed: %v", err)
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+func (c *CoinbaseClient) subscribeUser(ctx context.Context) (*websocket.Conn, error) {
+	token, err := c.buildJWT("")
+	if err != nil {
+		return nil, err
+	}
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, c.wsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	subscribe := map[string]interface{}{
+		"type":    "subscribe",
+		"channel": "user",
+		"jwt":     token,
+	}
+	if err := conn.WriteJSON(subscribe); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// coinbaseUserOrder is an order as reported on the user channel
+type coinbaseUserOrder struct {
+	OrderID            string    `json:"order_id"`
+	ClientOrderID      string    `json:"client_order_id"`
+	ProductID          string    `json:"product_id"`
+	OrderSide          string    `json:"order_side"`
+	Status             string    `json:"status"`
+	CumulativeQuantity string    `json:"cumulative_quantity"`
+	AvgPrice           string    `json:"avg_price"`
+	TotalFees          string    `json:"total_fees"`
+	CreationTime       time.Time `json:"creation_time"`
+}
+
+func (c *CoinbaseClient) readExecutions(ctx context.Context, conn *websocket.Conn, seen map[string]coinbaseUserOrder, out chan<- ExecutionReport) error {
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		var msg struct {
+			Channel string `json:"channel"`
+			Type    string `json:"type"`
+			Message string `json:"message"`
+			Events  []struct {
+				Type   string              `json:"type"`
+				Orders []coinbaseUserOrder `json:"orders"`
+			} `json:"events"`
+		}
+		if err := conn.ReadJSON(&msg); err != nil {
+			return err
+		}
+		if msg.Type == "error" {
+			return fmt.Errorf("coinbase: %s", msg.Message)
+		}
+		if msg.Channel != "user" {
+			continue
+		}
+
+		for _, event := range msg.Events {
+			for _, o := range event.Orders {
+				prev, known := seen[o.OrderID]
+				seen[o.OrderID] = o
+				// The snapshot only seeds state for orders placed before we connected
+				if event.Type == "snapshot" {
+					continue
+				}
+
+				report := c.executionReport(o, prev, known)
+				select {
+				case out <- report:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+
+				if report.Status == order.Filled || report.Status == order.Cancelled || report.Status == order.Rejected {
+					delete(seen, o.OrderID)
+				}
+			}
+		}
+	}
+}
+
+func (c *CoinbaseClient) executionReport(o, prev coinbaseUserOrder, known bool) ExecutionReport {
+	side := order.Buy
+	if o.OrderSide == "SELL" {
+		side = order.Sell
+	}
+
+	symbol := o.ProductID
+	if s, err := c.FromExchange(o.ProductID); err == nil {
+		symbol = s.String()
+	}
+
+	filled, _ := strconv.ParseFloat(o.CumulativeQuantity, 64)
+	avg, _ := strconv.ParseFloat(o.AvgPrice, 64)
+	fees, _ := strconv.ParseFloat(o.TotalFees, 64)
+
+	report := ExecutionReport{
+		OrderID:        o.OrderID,
+		ClientOrderID:  o.ClientOrderID,
+		Symbol:         symbol,
+		Side:           side,
+		Status:         coinbaseOrder{Status: o.Status, FilledSize: o.CumulativeQuantity}.status(),
+		FilledQuantity: filled,
+		AveragePrice:   avg,
+		Timestamp:      time.Now(),
+	}
+
+	var prevFilled, prevAvg, prevFees float64
+	if known {
+		prevFilled, _ = strconv.ParseFloat(prev.CumulativeQuantity, 64)
+		prevAvg, _ = strconv.ParseFloat(prev.AvgPrice, 64)
+		prevFees, _ = strconv.ParseFloat(prev.TotalFees, 64)
+	}
+	if delta := filled - prevFilled; delta > 0 {
+		report.LastFillQuantity = delta
+		report.LastFillPrice = (avg*filled - prevAvg*prevFilled) / delta
+		report.Fee = fees - prevFees
+		_, report.FeeCurrency = splitSymbol(symbol)
+	}
+	return report
+}
+
+// coinbaseWSMessage is the envelope of Advanced Trade websocket messages
+type coinbaseWSMessage struct {
+	Channel string `json:"channel"`
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	Events  []struct {
+		Type   string `json:"type"`
+		Trades []struct {
+			ProductID string    `json:"product_id"`
+			Price     string    `json:"price"`
+			Size      string    `json:"size"`
+			Side      string    `json:"side"`
+			Time      time.Time `json:"time"`
+		} `json:"trades"`
+	} `json:"events"`
+}
+
+// coinbaseOrder is the subset of historical order fields we rely on
+type coinbaseOrder struct {
+	OrderID    string `json:"order_id"`
+	Status     string `json:"status"`
+	FilledSize string `json:"filled_size"`
+}
+
+func (o coinbaseOrder) status() order.Status {
+	filled, _ := strconv.ParseFloat(o.FilledSize, 64)
+	switch o.Status {
+	case "PENDING", "QUEUED":
+		return order.SentToExchange
+	case "OPEN", "CANCEL_QUEUED":
+		if filled > 0 {
+			return order.PartiallyFilled
+		}
+		return order.SentToExchange
+	case "FILLED":
+		return order.Filled
+	case "CANCELLED", "EXPIRED":
+		return order.Cancelled
+	case "FAILED":
+		return order.Rejected
+	default:
+		return order.Failed
+	}
+}
+
+// coinbaseAccount is a single brokerage account (one per currency)
+type coinbaseAccount struct {
+	Currency         string `json:"currency"`
+	AvailableBalance struct {
+		Value string `json:"value"`
+	} `json:"available_balance"`
+	Hold struct {
+		Value string `json:"value"`
+	} `json:"hold"`
+}
+
+func (c *CoinbaseClient) request(ctx context.Context, method, path string, query url.Values, body interface{}, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	endpoint := c.restURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := c.authenticate(req, path, payload); err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		var apiErr struct {
+			Error   string `json:"error"`
+			Message string `json:"message"`
+		}
+		json.Unmarshal(data, &apiErr)
+		e := coinbaseError(apiErr.Error, fmt.Sprintf("HTTP %d: %s", resp.StatusCode, apiErr.Message))
+		switch {
+		case e.Err != nil:
+		case resp.StatusCode == http.StatusNotFound:
+			e.Err = ErrOrderNotFound
+		case resp.StatusCode == http.StatusServiceUnavailable:
+			e.Err = ErrMaintenance
+		case resp.StatusCode == http.StatusBadGateway, resp.StatusCode == http.StatusGatewayTimeout:
+			// The gateway may have forwarded the request before failing
+			e.Err = ErrUnknownOutcome
+		}
+		return e
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// coinbaseErrorKinds maps fragments of Coinbase failure reasons onto the
+// common errors, e.g. PREVIEW_INSUFFICIENT_FUND or INVALID_LIMIT_PRICE
+var coinbaseErrorKinds = []struct {
+	fragment string
+	err      error
+}{
+	{"INSUFFICIENT_FUND", ErrInsufficientFunds},
+	{"UNKNOWN_CANCEL_ORDER", ErrOrderNotFound},
+	{"NOT_FOUND", ErrOrderNotFound},
+	{"ORDER_ENTRY_DISABLED", ErrMaintenance},
+	{"CANCEL_ONLY", ErrMaintenance},
+	{"POST_ONLY_MODE", ErrMaintenance},
+	{"INVALID", ErrInvalidOrder},
+	{"TOO_SMALL", ErrInvalidOrder},
+	{"TOO_LARGE", ErrInvalidOrder},
+	{"UNSUPPORTED_ORDER_CONFIGURATION", ErrInvalidOrder},
+	{"CANNOT_EDIT", ErrInvalidOrder},
+}
+
+// coinbaseError converts a Coinbase failure reason into a VenueError
+func coinbaseError(reason, message string) *VenueError {
+	e := &VenueError{Exchange: "coinbase", Code: reason, Message: message}
+	for _, kind := range coinbaseErrorKinds {
+		if reason != "" && strings.Contains(reason, kind.fragment) {
+			e.Err = kind.err
+			break
+		}
+	}
+	return e
+}
+
+// authenticate signs a REST request with either a CDP JWT or the legacy
+// CB-ACCESS HMAC headers. Unauthenticated requests are left untouched.
+func (c *CoinbaseClient) authenticate(req *http.Request, path string, payload []byte) error {
+	if c.apiKey == "" {
+		return nil
+	}
+
+	if c.signingKey != nil {
+		token, err := c.buildJWT(req.Method + " " + req.URL.Host + path)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(c.apiSecret))
+	mac.Write([]byte(timestamp + req.Method + path + string(payload)))
+
+	req.Header.Set("CB-ACCESS-KEY", c.apiKey)
+	req.Header.Set("CB-ACCESS-SIGN", hex.EncodeToString(mac.Sum(nil)))
+	req.Header.Set("CB-ACCESS-TIMESTAMP", timestamp)
+	return nil
+}
+
+// buildJWT creates a short-lived ES256 token, scoped to a single request URI
+// when one is given
+func (c *CoinbaseClient) buildJWT(uri string) (string, error) {
+	now := time.Now().Unix()
+
+	header, err := json.Marshal(map[string]string{
+		"alg":   "ES256",
+		"typ":   "JWT",
+		"kid":   c.apiKey,
+		"nonce": randomHex(16),
+	})
+	if err != nil {
+		return "", err
+	}
+	payload := map[string]interface{}{
+		"sub": c.apiKey,
+		"iss": "cdp",
+		"nbf": now,
+		"exp": now + 120,
+	}
+	// Websocket tokens are not scoped to a request
+	if uri != "" {
+		payload["uri"] = uri
+	}
+	claims, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, c.signingKey, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	// JWS expects the fixed-width concatenation r || s
+	size := (c.signingKey.Curve.Params().BitSize + 7) / 8
+	sig := make([]byte, 2*size)
+	r.FillBytes(sig[:size])
+	s.FillBytes(sig[size:])
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// ToExchange converts a canonical symbol into a Coinbase product ID (BTC-USD)
+func (c *CoinbaseClient) ToExchange(s Symbol) string {
+	return s.Base + "-" + s.Quote
+}
+
+// FromExchange converts a Coinbase product ID into a canonical symbol
+func (c *CoinbaseClient) FromExchange(native string) (Symbol, error) {
+	return ParseSymbol(native)
+}
+
+// CoinbaseProductID converts a symbol such as BTCUSD or BTC/USD into a
+// Coinbase product ID (BTC-USD)
+func CoinbaseProductID(symbol string) string {
+	return nativeSymbol(&CoinbaseClient{}, symbol)
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		// Fall back to a time based value, uniqueness is all we need
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(buf)
+}
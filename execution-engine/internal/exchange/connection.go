@@ -0,0 +1,138 @@
+package exchange
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ConnectionState is the state of a websocket stream
+type ConnectionState int
+
+const (
+	Connected    ConnectionState = iota // Stream is live
+	Reconnecting                        // Stream dropped and is being reopened
+	Disconnected                        // Stream was closed and will not reconnect
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case Connected:
+		return "connected"
+	case Reconnecting:
+		return "reconnecting"
+	default:
+		return "disconnected"
+	}
+}
+
+// ConnectionEvent reports a state change of one of an exchange's streams
+type ConnectionEvent struct {
+	Exchange  string
+	Stream    string // trades, book, executions
+	Symbol    string
+	State     ConnectionState
+	Attempt   int   // reconnect attempt, while Reconnecting
+	Err       error // cause of the last failure, if any
+	Timestamp time.Time
+}
+
+// ConnectionNotifier is implemented by exchanges that report the state of
+// their streams
+type ConnectionNotifier interface {
+	// ConnectionEvents delivers state changes until the context is cancelled
+	ConnectionEvents(ctx context.Context) <-chan ConnectionEvent
+}
+
+// connectionEvents fans connection state changes out to subscribers
+type connectionEvents struct {
+	subs []chan ConnectionEvent
+	mu   sync.Mutex
+}
+
+func (e *connectionEvents) subscribe(ctx context.Context) <-chan ConnectionEvent {
+	ch := make(chan ConnectionEvent, 100)
+
+	e.mu.Lock()
+	e.subs = append(e.subs, ch)
+	e.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		for i, sub := range e.subs {
+			if sub == ch {
+				e.subs = append(e.subs[:i], e.subs[i+1:]...)
+				close(ch)
+				break
+			}
+		}
+	}()
+
+	return ch
+}
+
+// publish delivers an event without blocking the stream that raised it
+func (e *connectionEvents) publish(ev ConnectionEvent) {
+	if ev.Timestamp.IsZero() {
+		ev.Timestamp = time.Now()
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, ch := range e.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// ConnectionEvents merges the connection events of every exchange that
+// reports them, including exchanges registered later, tagging each event
+// with the exchange name
+func (m *Manager) ConnectionEvents(ctx context.Context) <-chan ConnectionEvent {
+	out := make(chan ConnectionEvent, 100)
+
+	m.watchExchanges(ctx, func(exCtx context.Context, name string, ex Interface) {
+		notifier, ok := ex.(ConnectionNotifier)
+		if !ok {
+			return
+		}
+
+		go func(name string, in <-chan ConnectionEvent) {
+			for ev := range in {
+				ev.Exchange = name
+				select {
+				case out <- ev:
+				case <-exCtx.Done():
+					return
+				}
+			}
+		}(name, notifier.ConnectionEvents(exCtx))
+	})
+
+	return out
+}
+
+// backoff waits for the given attempt's delay, doubling from one second up
+// to a minute with random jitter so that streams dropped together do not
+// reconnect in lockstep. It returns false if the context ends first.
+func backoff(ctx context.Context, attempt int) bool {
+	delay := time.Second << uint(min(attempt, 6))
+	if delay > time.Minute {
+		delay = time.Minute
+	}
+	delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
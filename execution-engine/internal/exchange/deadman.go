@@ -0,0 +1,63 @@
+package exchange
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// DeadMansSwitch is implemented by exchanges that cancel all open orders on
+// their own unless a countdown is renewed in time. CancelAllAfter restarts
+// the countdown; a zero timeout disarms it.
+type DeadMansSwitch interface {
+	CancelAllAfter(ctx context.Context, timeout time.Duration) error
+}
+
+// RunDeadMansSwitch arms the dead man's switch of every exchange that has
+// one, including exchanges registered later, and renews it every interval
+// until the context ends. Should the engine stop heartbeating, for whatever
+// reason, the exchanges cancel all open orders once timeout has passed since
+// the last heartbeat. The interval must leave room for a few failed
+// heartbeats within the timeout.
+func (m *Manager) RunDeadMansSwitch(ctx context.Context, timeout, interval time.Duration) {
+	m.watchExchanges(ctx, func(exCtx context.Context, name string, ex Interface) {
+		if _, ok := ex.(DeadMansSwitch); !ok {
+			return
+		}
+		log.Printf("Arming dead man's switch on %s: orders are cancelled %v after the last heartbeat", name, timeout)
+		go m.heartbeat(exCtx, name, ex, timeout, interval)
+	})
+	<-ctx.Done()
+}
+
+// heartbeat renews an exchange's countdown until the context ends or the
+// exchange is removed or replaced
+func (m *Manager) heartbeat(ctx context.Context, name string, ex Interface, timeout, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		current, release, ok := m.acquire(name)
+		if !ok || current != ex {
+			if ok {
+				release()
+			}
+			return
+		}
+		// Heartbeats bypass the circuit breaker so that a venue failing
+		// other calls keeps its orders as long as it answers these
+		hbCtx, cancel := context.WithTimeout(ctx, interval)
+		err := ex.(DeadMansSwitch).CancelAllAfter(hbCtx, timeout)
+		cancel()
+		release()
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Dead man's switch heartbeat to %s failed: %v", name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
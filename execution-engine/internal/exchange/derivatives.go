@@ -0,0 +1,161 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/trading-system/execution-engine/internal/order"
+)
+
+// MarginMode selects how collateral is shared between positions
+type MarginMode int
+
+const (
+	CrossMargin    MarginMode = iota // Collateral shared across positions
+	IsolatedMargin                   // Collateral assigned per position
+)
+
+// PositionMode selects whether long and short positions are netted
+type PositionMode int
+
+const (
+	OneWayMode PositionMode = iota // A single net position per symbol
+	HedgeMode                      // Separate long and short positions
+)
+
+// DerivativesAccount is implemented by exchanges with leveraged futures
+// accounts. Orders on these venues honour ReduceOnly and PositionSide.
+type DerivativesAccount interface {
+	SetLeverage(ctx context.Context, symbol string, leverage int) error
+	SetMarginMode(ctx context.Context, symbol string, mode MarginMode) error
+	SetPositionMode(ctx context.Context, mode PositionMode) error
+}
+
+// Position is an open derivatives position
+type Position struct {
+	Symbol           string
+	Side             order.PositionSide // PositionBoth in one-way mode
+	Size             float64            // signed: negative for short positions
+	EntryPrice       float64
+	MarkPrice        float64
+	Notional         float64
+	UnrealizedPnL    float64
+	Margin           float64 // isolated margin, or initial margin under cross margin
+	LiquidationPrice float64
+	Leverage         int
+	MarginMode       MarginMode
+}
+
+// FundingRate is a funding payment rate applied at a point in time
+type FundingRate struct {
+	Symbol string
+	Rate   float64
+	Time   time.Time
+}
+
+// FundingInfo is the current mark price and funding state of a perpetual
+type FundingInfo struct {
+	Symbol          string
+	MarkPrice       float64
+	Rate            float64 // rate applied at the next funding time
+	NextFundingTime time.Time
+}
+
+// PositionProvider is implemented by exchanges that expose derivatives
+// positions and funding data
+type PositionProvider interface {
+	// GetPositions returns all open positions
+	GetPositions(ctx context.Context) ([]Position, error)
+	GetFundingInfo(ctx context.Context, symbol string) (*FundingInfo, error)
+	// GetFundingHistory returns funding rates applied between start and
+	// end, oldest first. Zero times leave the range open.
+	GetFundingHistory(ctx context.Context, symbol string, start, end time.Time) ([]FundingRate, error)
+}
+
+// checkSpotOrder rejects derivatives parameters on spot venues, where they
+// would silently be ignored
+func checkSpotOrder(o *order.Order) error {
+	if o.ReduceOnly || o.PositionSide != order.PositionBoth {
+		return fmt.Errorf("%w: reduce-only and position side require a derivatives venue", ErrInvalidOrder)
+	}
+	return nil
+}
+
+func (m *Manager) derivatives(exchangeName string) (DerivativesAccount, error) {
+	ex, ok := m.GetExchange(exchangeName)
+	if !ok {
+		return nil, ErrExchangeNotFound
+	}
+	account, ok := ex.(DerivativesAccount)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return account, nil
+}
+
+// SetLeverage sets the leverage of a symbol on the specified exchange
+func (m *Manager) SetLeverage(ctx context.Context, exchangeName, symbol string, leverage int) error {
+	account, err := m.derivatives(exchangeName)
+	if err != nil {
+		return err
+	}
+	return account.SetLeverage(ctx, symbol, leverage)
+}
+
+// SetMarginMode sets the margin mode of a symbol on the specified exchange
+func (m *Manager) SetMarginMode(ctx context.Context, exchangeName, symbol string, mode MarginMode) error {
+	account, err := m.derivatives(exchangeName)
+	if err != nil {
+		return err
+	}
+	return account.SetMarginMode(ctx, symbol, mode)
+}
+
+// SetPositionMode switches the account position mode on the specified exchange
+func (m *Manager) SetPositionMode(ctx context.Context, exchangeName string, mode PositionMode) error {
+	account, err := m.derivatives(exchangeName)
+	if err != nil {
+		return err
+	}
+	return account.SetPositionMode(ctx, mode)
+}
+
+func (m *Manager) positions(exchangeName string) (PositionProvider, error) {
+	ex, ok := m.GetExchange(exchangeName)
+	if !ok {
+		return nil, ErrExchangeNotFound
+	}
+	provider, ok := ex.(PositionProvider)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return provider, nil
+}
+
+// GetPositions returns the open positions on the specified exchange
+func (m *Manager) GetPositions(ctx context.Context, exchangeName string) ([]Position, error) {
+	provider, err := m.positions(exchangeName)
+	if err != nil {
+		return nil, err
+	}
+	return provider.GetPositions(ctx)
+}
+
+// GetFundingInfo returns the funding state of a perpetual on the specified exchange
+func (m *Manager) GetFundingInfo(ctx context.Context, exchangeName, symbol string) (*FundingInfo, error) {
+	provider, err := m.positions(exchangeName)
+	if err != nil {
+		return nil, err
+	}
+	return provider.GetFundingInfo(ctx, symbol)
+}
+
+// GetFundingHistory returns past funding rates of a perpetual on the specified exchange
+func (m *Manager) GetFundingHistory(ctx context.Context, exchangeName, symbol string, start, end time.Time) ([]FundingRate, error) {
+	provider, err := m.positions(exchangeName)
+	if err != nil {
+		return nil, err
+	}
+	return provider.GetFundingHistory(ctx, symbol, start, end)
+}
@@ -0,0 +1,155 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/trading-system/execution-engine/internal/order"
+)
+
+// newTestBinance returns a connected client whose futures API is served by
+// a stub
+func newTestBinance(t *testing.T, handler http.HandlerFunc) *BinanceClient {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	b := NewBinanceClient()
+	b.client.APIKey = "key"
+	b.client.SecretKey = "secret"
+	b.client.BaseURL = srv.URL
+	b.wallet.APIKey = "key"
+	b.wallet.SecretKey = "secret"
+	b.wallet.BaseURL = srv.URL
+	b.connected = true
+	return b
+}
+
+func TestBinanceGetPositions(t *testing.T) {
+	b := newTestBinance(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/fapi/v2/positionRisk" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("X-MBX-APIKEY") != "key" || r.URL.Query().Get("signature") == "" {
+			t.Error("request not signed")
+		}
+		w.Write([]byte(`[
+			{"symbol":"BTCUSDT","positionAmt":"0.5","entryPrice":"60000","markPrice":"61000","unRealizedProfit":"500",
+			 "liquidationPrice":"50000","leverage":"10","marginType":"isolated","isolatedMargin":"3050",
+			 "positionSide":"LONG","notional":"30500"},
+			{"symbol":"ETHUSDT","positionAmt":"-2","entryPrice":"3000","markPrice":"2900","unRealizedProfit":"200",
+			 "liquidationPrice":"3500","leverage":"5","marginType":"cross","isolatedMargin":"0",
+			 "positionSide":"BOTH","notional":"-5800"},
+			{"symbol":"SOLUSDT","positionAmt":"0","entryPrice":"0","markPrice":"150","unRealizedProfit":"0",
+			 "liquidationPrice":"0","leverage":"20","marginType":"cross","isolatedMargin":"0",
+			 "positionSide":"BOTH","notional":"0"}
+		]`))
+	})
+
+	positions, err := b.GetPositions(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(positions) != 2 {
+		t.Fatalf("got %d positions, want 2 without the empty one", len(positions))
+	}
+
+	long := positions[0]
+	if long.Symbol != "BTC/USDT" || long.Side != order.PositionLong || long.Size != 0.5 {
+		t.Errorf("unexpected long position %+v", long)
+	}
+	if long.MarginMode != IsolatedMargin || long.Margin != 3050 || long.Leverage != 10 {
+		t.Errorf("long margin = %v %v x%d, want isolated 3050 x10", long.MarginMode, long.Margin, long.Leverage)
+	}
+	if long.EntryPrice != 60000 || long.MarkPrice != 61000 || long.UnrealizedPnL != 500 || long.LiquidationPrice != 50000 {
+		t.Errorf("unexpected long prices %+v", long)
+	}
+
+	short := positions[1]
+	if short.Symbol != "ETH/USDT" || short.Side != order.PositionBoth || short.Size != -2 {
+		t.Errorf("unexpected short position %+v", short)
+	}
+	// Cross margin is the initial margin of the notional at the leverage
+	if short.MarginMode != CrossMargin || short.Margin != 1160 {
+		t.Errorf("short margin = %v %v, want cross 1160", short.MarginMode, short.Margin)
+	}
+}
+
+func TestBinanceGetFundingInfo(t *testing.T) {
+	next := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	b := newTestBinance(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/fapi/v1/premiumIndex" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.URL.Query().Get("symbol"); got != "BTCUSDT" {
+			t.Errorf("symbol = %q, want BTCUSDT", got)
+		}
+		// A single symbol is answered with an object rather than a list
+		w.Write([]byte(`{"symbol":"BTCUSDT","markPrice":"61000.5","indexPrice":"61010","lastFundingRate":"0.0001",
+			"nextFundingTime":1704096000000,"time":1704090000000}`))
+	})
+
+	info, err := b.GetFundingInfo(context.Background(), "BTC/USDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Symbol != "BTC/USDT" || info.MarkPrice != 61000.5 || info.Rate != 0.0001 {
+		t.Errorf("unexpected funding info %+v", info)
+	}
+	if !info.NextFundingTime.Equal(next) {
+		t.Errorf("next funding = %v, want %v", info.NextFundingTime, next)
+	}
+}
+
+func TestManagerDerivativesNotSupported(t *testing.T) {
+	sim := NewSimExchange(SimConfig{})
+	m := NewManager(map[string]Interface{"sim": sim})
+
+	if _, err := m.GetPositions(context.Background(), "sim"); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("GetPositions err = %v, want ErrNotSupported", err)
+	}
+	if err := m.SetLeverage(context.Background(), "sim", "BTC/USDT", 5); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("SetLeverage err = %v, want ErrNotSupported", err)
+	}
+	if _, err := m.GetFundingInfo(context.Background(), "missing", "BTC/USDT"); !errors.Is(err, ErrExchangeNotFound) {
+		t.Errorf("GetFundingInfo err = %v, want ErrExchangeNotFound", err)
+	}
+
+	// Spot venues reject derivatives parameters on orders
+	sim.Connect()
+	o := &order.Order{Symbol: "BTC/USDT", Type: order.Market, Side: order.Buy, Quantity: 1, ReduceOnly: true}
+	if _, err := sim.PlaceOrder(context.Background(), o); !errors.Is(err, ErrInvalidOrder) {
+		t.Errorf("reduce-only on spot err = %v, want ErrInvalidOrder", err)
+	}
+}
+
+func TestBinanceDerivativesErrors(t *testing.T) {
+	b := newTestBinance(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":-4028,"msg":"Leverage 200 is not valid"}`))
+	})
+
+	var venueErr *VenueError
+	if err := b.SetLeverage(context.Background(), "BTC/USDT", 200); !errors.As(err, &venueErr) || venueErr.Code != "-4028" {
+		t.Errorf("SetLeverage err = %v, want venue error -4028", err)
+	}
+	if _, err := b.GetPositions(context.Background()); !errors.As(err, &venueErr) {
+		t.Errorf("GetPositions err = %v, want a venue error", err)
+	}
+
+	b.connected = false
+	if _, err := b.GetFundingInfo(context.Background(), "BTC/USDT"); !errors.Is(err, ErrNotConnected) {
+		t.Errorf("GetFundingInfo err = %v, want ErrNotConnected", err)
+	}
+	if _, err := b.GetFundingHistory(context.Background(), "BTC/USDT", time.Time{}, time.Time{}); !errors.Is(err, ErrNotConnected) {
+		t.Errorf("GetFundingHistory err = %v, want ErrNotConnected", err)
+	}
+}
@@ -0,0 +1,110 @@
+package exchange
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Environment selects which deployment of an exchange an adapter trades on
+type Environment int
+
+const (
+	Production Environment = iota // Live trading
+	Sandbox                       // Binance futures testnet, Coinbase sandbox
+)
+
+func (e Environment) String() string {
+	if e == Sandbox {
+		return "sandbox"
+	}
+	return "production"
+}
+
+// ParseEnvironment parses an environment name. An empty name is production.
+func ParseEnvironment(s string) (Environment, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "production", "prod", "live":
+		return Production, nil
+	case "sandbox", "testnet", "test":
+		return Sandbox, nil
+	}
+	return Production, fmt.Errorf("unknown exchange environment %q", s)
+}
+
+// Endpoints are the base URLs of an exchange deployment
+type Endpoints struct {
+	REST             string
+	Websocket        string
+	PrivateWebsocket string // authenticated feed, on venues that have a separate one
+}
+
+// merge returns the endpoints with any fields set in override replaced
+func (e Endpoints) merge(override Endpoints) Endpoints {
+	if override.REST != "" {
+		e.REST = override.REST
+	}
+	if override.Websocket != "" {
+		e.Websocket = override.Websocket
+	}
+	if override.PrivateWebsocket != "" {
+		e.PrivateWebsocket = override.PrivateWebsocket
+	}
+	return e
+}
+
+// EnvironmentConfig selects the deployment and credentials of an adapter
+type EnvironmentConfig struct {
+	Environment Environment
+	// Endpoints override the environment's URLs field by field, e.g. to
+	// point an adapter at an httptest server
+	Endpoints Endpoints
+	APIKey    string
+	APISecret string
+}
+
+// Configurable is implemented by adapters whose environment and
+// credentials can be set from configuration. Configure must be called
+// before Connect.
+type Configurable interface {
+	Configure(cfg EnvironmentConfig) error
+}
+
+// resolveEndpoints returns the URLs for a configuration given an exchange's
+// known deployments
+func resolveEndpoints(exchangeName string, known map[Environment]Endpoints, cfg EnvironmentConfig) (Endpoints, error) {
+	endpoints, ok := known[cfg.Environment]
+	if !ok && cfg.Endpoints.REST == "" {
+		return Endpoints{}, fmt.Errorf("%w: %s has no %s environment", ErrNotSupported, exchangeName, cfg.Environment)
+	}
+	return endpoints.merge(cfg.Endpoints), nil
+}
+
+// LoadEnvironmentConfig reads an exchange's configuration from environment
+// variables prefixed with its upper-cased name, e.g. BINANCE_ENV,
+// BINANCE_API_KEY, BINANCE_API_SECRET, BINANCE_REST_URL, BINANCE_WS_URL and
+// BINANCE_PRIVATE_WS_URL. EXCHANGE_ENV sets the default environment for all
+// exchanges.
+func LoadEnvironmentConfig(exchangeName string) (EnvironmentConfig, error) {
+	prefix := strings.ToUpper(exchangeName) + "_"
+
+	name := os.Getenv(prefix + "ENV")
+	if name == "" {
+		name = os.Getenv("EXCHANGE_ENV")
+	}
+	env, err := ParseEnvironment(name)
+	if err != nil {
+		return EnvironmentConfig{}, fmt.Errorf("%s: %w", exchangeName, err)
+	}
+
+	return EnvironmentConfig{
+		Environment: env,
+		Endpoints: Endpoints{
+			REST:             os.Getenv(prefix + "REST_URL"),
+			Websocket:        os.Getenv(prefix + "WS_URL"),
+			PrivateWebsocket: os.Getenv(prefix + "PRIVATE_WS_URL"),
+		},
+		APIKey:    os.Getenv(prefix + "API_KEY"),
+		APISecret: os.Getenv(prefix + "API_SECRET"),
+	}, nil
+}
@@ -0,0 +1,126 @@
+package exchange
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLoadEnvironmentConfig(t *testing.T) {
+	t.Setenv("EXCHANGE_ENV", "testnet")
+	t.Setenv("KRAKEN_ENV", "live")
+	t.Setenv("KRAKEN_REST_URL", "http://localhost:8000")
+	t.Setenv("KRAKEN_API_KEY", "key")
+
+	cfg, err := LoadEnvironmentConfig("kraken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Environment != Production || cfg.Endpoints.REST != "http://localhost:8000" || cfg.APIKey != "key" {
+		t.Errorf("unexpected kraken config %+v", cfg)
+	}
+
+	// Exchanges without their own setting use the default
+	cfg, err = LoadEnvironmentConfig("binance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Environment != Sandbox {
+		t.Errorf("binance environment = %v, want sandbox", cfg.Environment)
+	}
+
+	t.Setenv("COINBASE_ENV", "staging")
+	if _, err := LoadEnvironmentConfig("coinbase"); err == nil {
+		t.Error("unknown environment accepted")
+	}
+}
+
+// newStubVenue serves 200 with the given body on one path and 404 elsewhere,
+// counting the requests made to the path
+func newStubVenue(t *testing.T, path, body string) (*httptest.Server, *int) {
+	t.Helper()
+	hits := new(int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			t.Errorf("unexpected path %s", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		*hits++
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, hits
+}
+
+func TestConfigureEndpoints(t *testing.T) {
+	t.Run("kraken", func(t *testing.T) {
+		srv, hits := newStubVenue(t, "/0/public/Time", `{"error":[],"result":{"unixtime":1700000000}}`)
+		k := NewKrakenClient()
+		if err := k.Configure(EnvironmentConfig{Endpoints: Endpoints{REST: srv.URL + "/"}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := k.Connect(); err != nil {
+			t.Fatal(err)
+		}
+		if *hits != 1 {
+			t.Errorf("stub hit %d times, want 1", *hits)
+		}
+		// The websockets keep their production URLs
+		if k.wsURL != krakenWSURL || k.wsAuthURL != krakenWSAuthURL {
+			t.Errorf("websockets = %s, %s", k.wsURL, k.wsAuthURL)
+		}
+	})
+
+	t.Run("coinbase", func(t *testing.T) {
+		srv, hits := newStubVenue(t, "/api/v3/brokerage/time", `{"iso":"2024-01-01T00:00:00Z"}`)
+		c := NewCoinbaseClient()
+		cfg := EnvironmentConfig{Environment: Sandbox, Endpoints: Endpoints{REST: srv.URL}, APIKey: "key", APISecret: "secret"}
+		if err := c.Configure(cfg); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Connect(); err != nil {
+			t.Fatal(err)
+		}
+		if *hits != 1 {
+			t.Errorf("stub hit %d times, want 1", *hits)
+		}
+		if c.wsURL != coinbaseWSURL {
+			t.Errorf("websocket = %s, want %s", c.wsURL, coinbaseWSURL)
+		}
+	})
+
+	t.Run("binance", func(t *testing.T) {
+		srv, hits := newStubVenue(t, "/fapi/v1/ping", `{}`)
+		b := NewBinanceClient()
+		if err := b.Configure(EnvironmentConfig{Endpoints: Endpoints{REST: srv.URL}, APIKey: "key", APISecret: "secret"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Connect(); err != nil {
+			t.Fatal(err)
+		}
+		if *hits != 1 {
+			t.Errorf("stub hit %d times, want 1", *hits)
+		}
+		// A custom server stands in for the wallet API too
+		if b.wallet.BaseURL != srv.URL || b.client.APIKey != "key" {
+			t.Errorf("wallet URL = %s, key = %q", b.wallet.BaseURL, b.client.APIKey)
+		}
+
+		err := b.Configure(EnvironmentConfig{Endpoints: Endpoints{REST: srv.URL, Websocket: "ws://localhost"}})
+		if !errors.Is(err, ErrNotSupported) {
+			t.Errorf("websocket override err = %v, want ErrNotSupported", err)
+		}
+	})
+}
+
+func TestConfigureUnknownEnvironment(t *testing.T) {
+	// Kraken has no spot sandbox and needs custom endpoints for one
+	if err := NewKrakenClient().Configure(EnvironmentConfig{Environment: Sandbox}); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("kraken sandbox err = %v, want ErrNotSupported", err)
+	}
+	if err := NewKrakenClient().Configure(EnvironmentConfig{Environment: Sandbox, Endpoints: Endpoints{REST: "http://localhost"}}); err != nil {
+		t.Errorf("kraken sandbox with endpoints err = %v", err)
+	}
+}
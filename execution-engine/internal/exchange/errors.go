@@ -0,0 +1,89 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// VenueError is an error reported by an exchange. It carries the venue's
+// own code and matches the common error the code maps to with errors.Is.
+type VenueError struct {
+	Exchange string
+	Code     string
+	Message  string
+	Err      error // common error, nil if the code is not classified
+}
+
+func (e *VenueError) Error() string {
+	if e.Code == "" || e.Code == e.Message {
+		return fmt.Sprintf("%s: %s", e.Exchange, e.Message)
+	}
+	return fmt.Sprintf("%s: %s (%s)", e.Exchange, e.Message, e.Code)
+}
+
+// Unwrap allows errors.Is(err, ErrInsufficientFunds) and the like
+func (e *VenueError) Unwrap() error {
+	return e.Err
+}
+
+// RetryClass says whether and how a failed call may be retried
+type RetryClass int
+
+const (
+	RetryTransient   RetryClass = iota // Retry after a backoff
+	RetryNever                         // The request itself is at fault
+	RetryUnavailable                   // The venue is down; retry once it is back
+	RetryUnsafe                        // The request may have been applied; check before retrying
+)
+
+func (c RetryClass) String() string {
+	switch c {
+	case RetryNever:
+		return "never"
+	case RetryUnavailable:
+		return "unavailable"
+	case RetryUnsafe:
+		return "unsafe"
+	}
+	return "transient"
+}
+
+// Classify returns how a failed call may be retried and the minimum delay
+// the venue asked for, if any. Errors not mapped to a common error are
+// assumed to be transient.
+func Classify(err error) (RetryClass, time.Duration) {
+	var rateLimited *RateLimitError
+	switch {
+	case errors.As(err, &rateLimited):
+		return RetryTransient, rateLimited.RetryAfter
+	case errors.Is(err, ErrUnknownOutcome):
+		return RetryUnsafe, 0
+	case errors.Is(err, ErrMaintenance), errors.Is(err, ErrCircuitOpen):
+		return RetryUnavailable, 0
+	case errors.Is(err, ErrInsufficientFunds), errors.Is(err, ErrInvalidOrder),
+		errors.Is(err, ErrOrderNotFound), errors.Is(err, ErrNotSupported),
+		errors.Is(err, ErrExchangeNotFound), errors.Is(err, context.Canceled):
+		return RetryNever, 0
+	}
+	return RetryTransient, 0
+}
+
+// placementError marks a placement or amendment that timed out after it
+// was sent as having an unknown outcome, since the venue may have received
+// it. Requests that never left, e.g. while waiting on the rate limiter, are
+// left as they are.
+func placementError(err error) error {
+	var unsent *unsentError
+	if err == nil || errors.Is(err, ErrUnknownOutcome) || errors.As(err, &unsent) {
+		return err
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) ||
+		(errors.As(err, &netErr) && netErr.Timeout()) {
+		return fmt.Errorf("%w: %w", ErrUnknownOutcome, err)
+	}
+	return err
+}
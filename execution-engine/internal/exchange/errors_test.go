@@ -0,0 +1,51 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPlacementErrorOnlyAfterSend(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Never answers, like a venue that lost the response
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	send := func(limiter *RateLimiter) error {
+		client := newRateLimitedClient(limiter, func(*http.Request) []Cost { return []Cost{{ClassOrders, 1}} }, nil)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return placementError(err)
+	}
+
+	// Timed out while queued on the limiter: never reached the venue
+	throttled := NewRateLimiter("test", RateLimit{Class: ClassOrders, Limit: 10, Interval: time.Second})
+	throttled.SetMaxWait(time.Hour)
+	throttled.Backoff(time.Minute)
+	err := send(throttled)
+	if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrUnknownOutcome) {
+		t.Errorf("queued err = %v, want DeadlineExceeded without ErrUnknownOutcome", err)
+	}
+	if class, _ := Classify(err); class != RetryTransient {
+		t.Errorf("queued class = %v, want RetryTransient", class)
+	}
+
+	// Timed out waiting for the response: may have been placed
+	err = send(NewRateLimiter("test", RateLimit{Class: ClassOrders, Limit: 10, Interval: time.Second}))
+	if !errors.Is(err, ErrUnknownOutcome) {
+		t.Errorf("sent err = %v, want ErrUnknownOutcome", err)
+	}
+}
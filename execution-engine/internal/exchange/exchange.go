@@ -2,6 +2,8 @@ package exchange
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"time"
 
 	"github.com/trading-system/execution-engine/internal/order"
@@ -20,6 +22,7 @@ type Interface interface {
 
 // TradeEvent represents a real-time trade event
 type TradeEvent struct {
+	Exchange  string
 	Symbol    string
 	Price     float64
 	Quantity  float64
@@ -28,31 +31,77 @@ type TradeEvent struct {
 
 // Manager handles multiple exchange connections
 type Manager struct {
-	exchanges map[string]Interface
+	exchanges     map[string]Interface
+	inflight      map[string]*sync.WaitGroup
+	changes       registryEvents
+	mu            sync.RWMutex
+	instruments   *InstrumentRegistry
+	health        map[string]*venueHealth
+	breakerConfig BreakerConfig
+	healthMu      sync.Mutex
+	fees          map[string]cachedFees // exchange/symbol -> account rates
+	feeDefaults   map[string]FeeSchedule
+	feesMu        sync.Mutex
 }
 
 // NewManager creates a new exchange manager
 func NewManager(exchanges map[string]Interface) *Manager {
-	return &Manager{exchanges: exchanges}
+	registered := make(map[string]Interface, len(exchanges))
+	inflight := make(map[string]*sync.WaitGroup, len(exchanges))
+	for name, ex := range exchanges {
+		registered[name] = ex
+		inflight[name] = &sync.WaitGroup{}
+	}
+	feeDefaults := make(map[string]FeeSchedule, len(DefaultFees))
+	for name, fees := range DefaultFees {
+		feeDefaults[name] = fees
+	}
+	return &Manager{
+		exchanges:     registered,
+		inflight:      inflight,
+		instruments:   NewInstrumentRegistry(),
+		health:        make(map[string]*venueHealth),
+		breakerConfig: DefaultBreakerConfig(),
+		fees:          make(map[string]cachedFees),
+		feeDefaults:   feeDefaults,
+	}
 }
 
 // GetExchange returns an exchange by name
 func (m *Manager) GetExchange(name string) (Interface, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	ex, ok := m.exchanges[name]
 	return ex, ok
 }
 
-// PlaceOrder places an order on the specified exchange
+// PlaceOrder places an order on the specified exchange. Orders to a venue
+// whose circuit breaker is open are refused with ErrCircuitOpen.
 func (m *Manager) PlaceOrder(ctx context.Context, exchangeName string, o *order.Order) (string, error) {
-	ex, ok := m.GetExchange(exchangeName)
-	if !ok {
-		return "", ErrExchangeNotFound
-	}
-	return ex.PlaceOrder(ctx, o)
+	var id string
+	err := m.call(exchangeName, func(ex Interface) error {
+		var err error
+		id, err = ex.PlaceOrder(ctx, o)
+		return placementError(err)
+	})
+	return id, err
 }
 
 // Common errors
 var (
-	ErrExchangeNotFound = errors.New("exchange not found")
-	ErrNotConnected     = errors.New("exchange not connected")
+	ErrExchangeNotFound  = errors.New("exchange not found")
+	ErrNotConnected      = errors.New("exchange not connected")
+	ErrOrderNotFound     = errors.New("order not found")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrInvalidOrder      = errors.New("invalid order")
+	ErrNotSupported      = errors.New("operation not supported by exchange")
+	ErrRateLimited       = errors.New("rate limit exceeded")
+	ErrCircuitOpen       = errors.New("exchange circuit breaker open")
+	ErrExchangeExists    = errors.New("exchange already registered")
+	ErrInvalidTransfer   = errors.New("invalid transfer")
+	ErrTransferNotFound  = errors.New("transfer not found")
+	ErrNotRecorded       = errors.New("call not in recording")
+	ErrReplaceFailed     = errors.New("order cancelled but replacement failed")
+	ErrUnknownOutcome    = errors.New("request outcome unknown")
+	ErrMaintenance       = errors.New("exchange under maintenance")
 )
\ No newline at end of file
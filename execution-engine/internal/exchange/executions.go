@@ -0,0 +1,73 @@
+package exchange
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/trading-system/execution-engine/internal/order"
+)
+
+// ExecutionReport is an update from a venue's private stream about one of
+// our orders. Reports caused by a trade carry the fill in the Last* fields.
+type ExecutionReport struct {
+	Exchange         string
+	OrderID          string
+	ClientOrderID    string
+	Symbol           string
+	Side             order.Side
+	Status           order.Status
+	FilledQuantity   float64 // cumulative
+	AveragePrice     float64
+	LastFillPrice    float64
+	LastFillQuantity float64
+	TradeID          string
+	Fee              float64 // fee of the last fill
+	FeeCurrency      string
+	Timestamp        time.Time
+}
+
+// IsFill reports whether the update carries a new execution
+func (r ExecutionReport) IsFill() bool {
+	return r.LastFillQuantity > 0
+}
+
+// ExecutionStreamer is implemented by exchanges with a private order and
+// fill stream (Binance user data, Kraken ownTrades, Coinbase user channel)
+type ExecutionStreamer interface {
+	// StreamExecutions delivers execution reports until the context is
+	// cancelled, reconnecting transparently when the stream drops
+	StreamExecutions(ctx context.Context) (<-chan ExecutionReport, error)
+}
+
+// StreamExecutions merges the private streams of every exchange that has
+// one, including exchanges registered later, into a single channel, tagging
+// each report with the exchange name
+func (m *Manager) StreamExecutions(ctx context.Context) (<-chan ExecutionReport, error) {
+	out := make(chan ExecutionReport, 1000)
+
+	m.watchExchanges(ctx, func(exCtx context.Context, name string, ex Interface) {
+		streamer, ok := ex.(ExecutionStreamer)
+		if !ok {
+			return
+		}
+		in, err := streamer.StreamExecutions(exCtx)
+		if err != nil {
+			log.Printf("Error opening %s execution stream: %v", name, err)
+			return
+		}
+
+		go func(name string, in <-chan ExecutionReport) {
+			for report := range in {
+				report.Exchange = name
+				select {
+				case out <- report:
+				case <-exCtx.Done():
+					return
+				}
+			}
+		}(name, in)
+	})
+
+	return out, nil
+}
@@ -0,0 +1,331 @@
+package exchange
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/trading-system/execution-engine/internal/order"
+)
+
+// FaultScenario configures the faults a FaultyExchange injects. Rates are
+// probabilities between 0 and 1. The same seed reproduces the same faults
+// for the same sequence of calls and messages.
+type FaultScenario struct {
+	Seed int64
+
+	// Calls
+	Latency     time.Duration // added to every call
+	Jitter      time.Duration // random extra latency up to this
+	ErrorRate   float64       // calls failing with one of Errors
+	Errors      []error       // defaults to ErrNotConnected
+	TimeoutRate float64       // calls hanging until Timeout or the context ends
+	Timeout     time.Duration // defaults to 10s
+
+	// Streams
+	DropRate         float64 // messages that are never delivered
+	ReorderRate      float64 // messages delivered after the one following them
+	DuplicateAckRate float64 // execution reports delivered twice
+	DisconnectRate   float64 // chance per message that the stream drops
+}
+
+// streamAction is the fate of a single stream message
+type streamAction int
+
+const (
+	deliver streamAction = iota
+	drop
+	hold // deliver after the next message
+	duplicate
+	disconnect
+)
+
+// FaultyExchange wraps an exchange and injects latency, errors, timeouts
+// and stream faults according to a scenario, for testing how callers cope
+// with an unreliable venue
+type FaultyExchange struct {
+	ex       Interface
+	scenario FaultScenario
+	rng      *rand.Rand // call faults
+	rngMu    sync.Mutex
+	streams  int
+	kill     chan struct{} // closed by DropConnection
+	mu       sync.Mutex
+}
+
+// NewFaultyExchange creates a fault-injecting wrapper around an exchange
+func NewFaultyExchange(ex Interface, scenario FaultScenario) *FaultyExchange {
+	if len(scenario.Errors) == 0 {
+		scenario.Errors = []error{ErrNotConnected}
+	}
+	if scenario.Timeout == 0 {
+		scenario.Timeout = 10 * time.Second
+	}
+	return &FaultyExchange{
+		ex:       ex,
+		scenario: scenario,
+		rng:      rand.New(rand.NewSource(scenario.Seed)),
+		kill:     make(chan struct{}),
+	}
+}
+
+// DropConnection closes every open stream as if the venue disconnected
+func (f *FaultyExchange) DropConnection() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	close(f.kill)
+	f.kill = make(chan struct{})
+}
+
+// inject applies call latency and decides whether the call fails. A timed
+// out call returns context.DeadlineExceeded once Timeout elapses.
+func (f *FaultyExchange) inject(ctx context.Context) (timedOut bool, err error) {
+	f.rngMu.Lock()
+	delay := f.scenario.Latency
+	if f.scenario.Jitter > 0 {
+		delay += time.Duration(f.rng.Int63n(int64(f.scenario.Jitter)))
+	}
+	timeout := f.rng.Float64() < f.scenario.TimeoutRate
+	fail := f.rng.Float64() < f.scenario.ErrorRate
+	injected := f.scenario.Errors[f.rng.Intn(len(f.scenario.Errors))]
+	f.rngMu.Unlock()
+
+	if timeout {
+		delay += f.scenario.Timeout
+	}
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return true, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	switch {
+	case timeout:
+		return true, context.DeadlineExceeded
+	case fail:
+		return false, injected
+	}
+	return false, nil
+}
+
+// newStream returns the fault source of a new stream and the channel that
+// signals a forced disconnect. Each stream gets its own source so that
+// concurrent streams do not perturb each other's faults.
+func (f *FaultyExchange) newStream() (*faultStream, <-chan struct{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.streams++
+	return &faultStream{
+		scenario: f.scenario,
+		rng:      rand.New(rand.NewSource(f.scenario.Seed + int64(f.streams))),
+	}, f.kill
+}
+
+// faultStream decides the fate of each message of one stream
+type faultStream struct {
+	scenario FaultScenario
+	rng      *rand.Rand
+	holding  bool
+}
+
+func (s *faultStream) next(acks bool) streamAction {
+	switch r := s.rng.Float64(); {
+	case r < s.scenario.DisconnectRate:
+		return disconnect
+	case s.rng.Float64() < s.scenario.DropRate:
+		return drop
+	case !s.holding && s.rng.Float64() < s.scenario.ReorderRate:
+		return hold
+	case acks && s.rng.Float64() < s.scenario.DuplicateAckRate:
+		return duplicate
+	}
+	return deliver
+}
+
+// Connect connects the wrapped exchange unless a fault is injected
+func (f *FaultyExchange) Connect() error {
+	if _, err := f.inject(context.Background()); err != nil {
+		return err
+	}
+	return f.ex.Connect()
+}
+
+// Disconnect disconnects the wrapped exchange
+func (f *FaultyExchange) Disconnect() error {
+	return f.ex.Disconnect()
+}
+
+// PlaceOrder places an order on the wrapped exchange unless a fault is
+// injected. Like a lost response, half of the timed out placements still
+// reach the venue.
+func (f *FaultyExchange) PlaceOrder(ctx context.Context, o *order.Order) (string, error) {
+	timedOut, err := f.inject(ctx)
+	if err == nil {
+		return f.ex.PlaceOrder(ctx, o)
+	}
+	if timedOut {
+		f.rngMu.Lock()
+		placed := f.rng.Intn(2) == 0
+		f.rngMu.Unlock()
+		if placed {
+			f.ex.PlaceOrder(context.Background(), o)
+		}
+	}
+	return "", err
+}
+
+// CancelOrder cancels an order on the wrapped exchange unless a fault is injected
+func (f *FaultyExchange) CancelOrder(orderID string) error {
+	if _, err := f.inject(context.Background()); err != nil {
+		return err
+	}
+	return f.ex.CancelOrder(orderID)
+}
+
+// GetOrderStatus returns an order's status unless a fault is injected
+func (f *FaultyExchange) GetOrderStatus(orderID string) (order.Status, error) {
+	if _, err := f.inject(context.Background()); err != nil {
+		return order.Pending, err
+	}
+	return f.ex.GetOrderStatus(orderID)
+}
+
+// GetBalance returns a balance unless a fault is injected
+func (f *FaultyExchange) GetBalance(currency string) (float64, error) {
+	if _, err := f.inject(context.Background()); err != nil {
+		return 0, err
+	}
+	return f.ex.GetBalance(currency)
+}
+
+// StreamTrades opens a trade stream that drops, reorders and disconnects
+// according to the scenario. A disconnect closes the channel.
+func (f *FaultyExchange) StreamTrades(ctx context.Context, symbol string) (chan TradeEvent, error) {
+	if _, err := f.inject(ctx); err != nil {
+		return nil, err
+	}
+	in, err := f.ex.StreamTrades(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	faults, kill := f.newStream()
+	out := make(chan TradeEvent, 1000)
+	go func() {
+		defer close(out)
+		defer drainTrades(in)
+
+		var held []TradeEvent
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-kill:
+				return
+			case ev, ok := <-in:
+				if !ok {
+					return
+				}
+				switch faults.next(false) {
+				case disconnect:
+					return
+				case drop:
+					continue
+				case hold:
+					faults.holding = true
+					held = append(held, ev)
+					continue
+				}
+				out <- ev
+				if faults.holding {
+					faults.holding = false
+					for _, h := range held {
+						out <- h
+					}
+					held = held[:0]
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// StreamExecutions opens the wrapped exchange's private stream, if it has
+// one, and drops, reorders, duplicates and disconnects reports according
+// to the scenario
+func (f *FaultyExchange) StreamExecutions(ctx context.Context) (<-chan ExecutionReport, error) {
+	streamer, ok := f.ex.(ExecutionStreamer)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	if _, err := f.inject(ctx); err != nil {
+		return nil, err
+	}
+	in, err := streamer.StreamExecutions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	faults, kill := f.newStream()
+	out := make(chan ExecutionReport, 1000)
+	go func() {
+		defer close(out)
+		defer drainExecutions(in)
+
+		var held []ExecutionReport
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-kill:
+				return
+			case report, ok := <-in:
+				if !ok {
+					return
+				}
+				switch faults.next(true) {
+				case disconnect:
+					return
+				case drop:
+					continue
+				case hold:
+					faults.holding = true
+					held = append(held, report)
+					continue
+				case duplicate:
+					out <- report
+				}
+				out <- report
+				if faults.holding {
+					faults.holding = false
+					for _, h := range held {
+						out <- h
+					}
+					held = held[:0]
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// drainTrades keeps the wrapped stream flowing after a simulated disconnect
+// so that its producer is never blocked
+func drainTrades(in <-chan TradeEvent) {
+	go func() {
+		for range in {
+		}
+	}()
+}
+
+func drainExecutions(in <-chan ExecutionReport) {
+	go func() {
+		for range in {
+		}
+	}()
+}
@@ -0,0 +1,91 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// scriptedTrades is a simulator whose trade stream delivers a fixed list of
+// trades and then closes
+type scriptedTrades struct {
+	*SimExchange
+	trades []TradeEvent
+}
+
+func (s *scriptedTrades) StreamTrades(ctx context.Context, symbol string) (chan TradeEvent, error) {
+	ch := make(chan TradeEvent, len(s.trades))
+	for _, ev := range s.trades {
+		ch <- ev
+	}
+	close(ch)
+	return ch, nil
+}
+
+// runFaultScenario makes a fixed sequence of calls and reads a stream
+// through a FaultyExchange, returning what the caller saw
+func runFaultScenario(t *testing.T, scenario FaultScenario) []string {
+	t.Helper()
+	sim := NewSimExchange(SimConfig{Balances: map[string]float64{"USDT": 1000}})
+	if err := sim.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	ex := &scriptedTrades{SimExchange: sim}
+	for i := 0; i < 100; i++ {
+		ex.trades = append(ex.trades, TradeEvent{Symbol: "BTC/USDT", Price: float64(i), Quantity: 1})
+	}
+	f := NewFaultyExchange(ex, scenario)
+
+	var seen []string
+	for i := 0; i < 50; i++ {
+		balance, err := f.GetBalance("USDT")
+		seen = append(seen, fmt.Sprintf("balance %v %v", balance, err))
+	}
+
+	trades, err := f.StreamTrades(context.Background(), "BTC/USDT")
+	if err != nil {
+		seen = append(seen, fmt.Sprintf("stream %v", err))
+		return seen
+	}
+	for ev := range trades {
+		seen = append(seen, fmt.Sprintf("trade %v", ev.Price))
+	}
+	return seen
+}
+
+func TestFaultyExchangeDeterministic(t *testing.T) {
+	scenario := FaultScenario{
+		Seed:           42,
+		ErrorRate:      0.3,
+		Errors:         []error{ErrNotConnected, ErrRateLimited, ErrMaintenance},
+		TimeoutRate:    0.1,
+		Timeout:        time.Millisecond,
+		DropRate:       0.2,
+		ReorderRate:    0.2,
+		DisconnectRate: 0.01,
+	}
+
+	first := runFaultScenario(t, scenario)
+	second := runFaultScenario(t, scenario)
+	if !reflect.DeepEqual(first, second) {
+		t.Fatalf("same seed gave different runs:\n%v\n%v", first, second)
+	}
+
+	// The scenario must actually have injected faults
+	clean := 0
+	for _, s := range first {
+		if s == "balance 1000 <nil>" {
+			clean++
+		}
+	}
+	if clean == 50 || len(first) == 150 {
+		t.Errorf("no faults injected: %v", first)
+	}
+
+	scenario.Seed = 43
+	if other := runFaultScenario(t, scenario); reflect.DeepEqual(first, other) {
+		t.Error("different seeds gave the same run")
+	}
+}
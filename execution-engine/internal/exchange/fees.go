@@ -0,0 +1,112 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/trading-system/execution-engine/internal/order"
+)
+
+// feeCacheTTL is how long account fee rates are reused before refreshing.
+// Fee tiers move with 30-day volume, so hourly is plenty.
+const feeCacheTTL = time.Hour
+
+// FeeSchedule is the fee rate charged on a symbol, as a fraction of notional
+type FeeSchedule struct {
+	Maker float64 // resting orders
+	Taker float64 // orders that cross the spread
+}
+
+// Rate returns the rate expected for an order type. Limit orders are
+// assumed to rest, so a marketable limit order is underestimated.
+func (f FeeSchedule) Rate(t order.Type) float64 {
+	if t == order.Market {
+		return f.Taker
+	}
+	return f.Maker
+}
+
+// FeeProvider is implemented by exchanges that report the account's
+// current fee tier
+type FeeProvider interface {
+	GetFees(ctx context.Context, symbol string) (*FeeSchedule, error)
+}
+
+// DefaultFees are the base tier rates of each venue, used when account
+// rates cannot be fetched
+var DefaultFees = map[string]FeeSchedule{
+	"binance":  {Maker: 0.0002, Taker: 0.0005},
+	"kraken":   {Maker: 0.0025, Taker: 0.0040},
+	"coinbase": {Maker: 0.0060, Taker: 0.0080},
+}
+
+// cachedFees is an account fee schedule and when it was fetched
+type cachedFees struct {
+	fees    FeeSchedule
+	fetched time.Time
+}
+
+// SetFeeSchedule overrides the static fallback rates of an exchange
+func (m *Manager) SetFeeSchedule(exchangeName string, fees FeeSchedule) {
+	m.feesMu.Lock()
+	defer m.feesMu.Unlock()
+	m.feeDefaults[exchangeName] = fees
+}
+
+// Fees returns the last known fee schedule of a symbol without calling the
+// exchange: the cached account rates if any, else the static fallback
+func (m *Manager) Fees(exchangeName, symbol string) FeeSchedule {
+	m.feesMu.Lock()
+	defer m.feesMu.Unlock()
+
+	if cached, ok := m.fees[exchangeName+"/"+symbol]; ok {
+		return cached.fees
+	}
+	return m.feeDefaults[exchangeName]
+}
+
+// GetFees returns the account fee schedule of a symbol, refreshing it from
+// the exchange once the cached rates expire. Exchanges that cannot report
+// their rates, or fail to, fall back to the static schedule.
+func (m *Manager) GetFees(ctx context.Context, exchangeName, symbol string) (FeeSchedule, error) {
+	ex, ok := m.GetExchange(exchangeName)
+	if !ok {
+		return FeeSchedule{}, ErrExchangeNotFound
+	}
+
+	key := exchangeName + "/" + symbol
+	m.feesMu.Lock()
+	cached, ok := m.fees[key]
+	m.feesMu.Unlock()
+	if ok && time.Since(cached.fetched) < feeCacheTTL {
+		return cached.fees, nil
+	}
+
+	provider, ok := ex.(FeeProvider)
+	if !ok {
+		return m.Fees(exchangeName, symbol), nil
+	}
+	fees, err := provider.GetFees(ctx, symbol)
+	if err != nil {
+		if !errors.Is(err, ErrNotSupported) {
+			log.Printf("Error fetching %s fees for %s: %v", exchangeName, symbol, err)
+		}
+		return m.Fees(exchangeName, symbol), nil
+	}
+
+	m.feesMu.Lock()
+	m.fees[key] = cachedFees{fees: *fees, fetched: time.Now()}
+	m.feesMu.Unlock()
+	return *fees, nil
+}
+
+// EstimateFee returns the fee expected for an order at its limit price
+func (m *Manager) EstimateFee(ctx context.Context, o *order.Order) (float64, error) {
+	fees, err := m.GetFees(ctx, o.Exchange, o.Symbol)
+	if err != nil {
+		return 0, err
+	}
+	return o.Price * o.Quantity * fees.Rate(o.Type), nil
+}
@@ -0,0 +1,445 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/trading-system/execution-engine/internal/order"
+)
+
+// BreakerState is the state of a venue's circuit breaker
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // Requests flow normally
+	BreakerOpen                         // Requests are refused
+	BreakerHalfOpen                     // A single probe request is allowed
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	default:
+		return "half-open"
+	}
+}
+
+// BreakerConfig controls when a venue's breaker trips and recovers
+type BreakerConfig struct {
+	ConsecutiveFailures int           // trip after this many failures in a row
+	ErrorRate           float64       // or when the windowed error rate reaches this
+	MinRequests         int           // requests needed before the error rate counts
+	Window              int           // number of recent requests in the error rate
+	OpenTimeout         time.Duration // how long to refuse requests before probing
+}
+
+// DefaultBreakerConfig returns the breaker settings used by NewManager
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		ConsecutiveFailures: 5,
+		ErrorRate:           0.5,
+		MinRequests:         10,
+		Window:              50,
+		OpenTimeout:         30 * time.Second,
+	}
+}
+
+// VenueHealth is a snapshot of an exchange's health
+type VenueHealth struct {
+	Exchange            string
+	Breaker             BreakerState
+	Requests            int64
+	Failures            int64
+	ErrorRate           float64       // over the recent window
+	Latency             time.Duration // moving average of request latency
+	ConsecutiveFailures int
+	LastError           string
+	LastFailure         time.Time
+	OpenedAt            time.Time
+	StreamsDown         int // websocket streams currently reconnecting
+}
+
+// Healthy reports whether requests are flowing normally
+func (h VenueHealth) Healthy() bool {
+	return h.Breaker == BreakerClosed && h.StreamsDown == 0
+}
+
+var (
+	breakerState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "exchange_circuit_breaker_state",
+			Help: "Circuit breaker state per exchange (0 = closed, 1 = open, 2 = half-open)",
+		},
+		[]string{"exchange"},
+	)
+
+	requestLatency = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "exchange_request_latency_seconds",
+			Help: "Moving average latency of exchange requests in seconds",
+		},
+		[]string{"exchange"},
+	)
+
+	requestErrorRate = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "exchange_request_error_rate",
+			Help: "Fraction of recent exchange requests that failed",
+		},
+		[]string{"exchange"},
+	)
+
+	streamsDown = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "exchange_streams_down",
+			Help: "Websocket streams currently reconnecting per exchange",
+		},
+		[]string{"exchange"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(breakerState)
+	prometheus.MustRegister(requestLatency)
+	prometheus.MustRegister(requestErrorRate)
+	prometheus.MustRegister(streamsDown)
+}
+
+// venueHealth tracks request outcomes and the breaker of one exchange
+type venueHealth struct {
+	config  BreakerConfig
+	health  VenueHealth
+	window  []bool // recent outcomes, true for failure
+	next    int
+	probing bool
+	streams map[string]ConnectionState
+	mu      sync.Mutex
+}
+
+func newVenueHealth(name string, config BreakerConfig) *venueHealth {
+	return &venueHealth{
+		config:  config,
+		health:  VenueHealth{Exchange: name},
+		window:  make([]bool, 0, config.Window),
+		streams: make(map[string]ConnectionState),
+	}
+}
+
+// allow reports whether a request may be sent, moving an open breaker to
+// half-open once its timeout has passed
+func (v *venueHealth) allow() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	switch v.health.Breaker {
+	case BreakerOpen:
+		if time.Since(v.health.OpenedAt) < v.config.OpenTimeout {
+			return fmt.Errorf("%s: %w", v.health.Exchange, ErrCircuitOpen)
+		}
+		v.setState(BreakerHalfOpen)
+		fallthrough
+	case BreakerHalfOpen:
+		if v.probing {
+			return fmt.Errorf("%s: %w", v.health.Exchange, ErrCircuitOpen)
+		}
+		v.probing = true
+	}
+	return nil
+}
+
+// record updates the statistics with a request's outcome
+func (v *venueHealth) record(latency time.Duration, err error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	failed := isVenueFailure(err)
+	v.health.Requests++
+	if v.health.Latency == 0 {
+		v.health.Latency = latency
+	} else {
+		v.health.Latency = (v.health.Latency*4 + latency) / 5
+	}
+
+	if len(v.window) < v.config.Window {
+		v.window = append(v.window, failed)
+	} else if v.config.Window > 0 {
+		v.window[v.next] = failed
+		v.next = (v.next + 1) % v.config.Window
+	}
+	failures := 0
+	for _, f := range v.window {
+		if f {
+			failures++
+		}
+	}
+	if len(v.window) > 0 {
+		v.health.ErrorRate = float64(failures) / float64(len(v.window))
+	}
+
+	if failed {
+		v.health.Failures++
+		v.health.ConsecutiveFailures++
+		v.health.LastError = err.Error()
+		v.health.LastFailure = time.Now()
+	} else {
+		v.health.ConsecutiveFailures = 0
+	}
+
+	switch {
+	case v.health.Breaker == BreakerHalfOpen:
+		v.probing = false
+		if failed {
+			v.trip()
+		} else {
+			v.reset()
+		}
+	case v.health.Breaker == BreakerClosed && failed:
+		if v.health.ConsecutiveFailures >= v.config.ConsecutiveFailures ||
+			(len(v.window) >= v.config.MinRequests && v.health.ErrorRate >= v.config.ErrorRate) {
+			v.trip()
+		}
+	}
+
+	requestLatency.WithLabelValues(v.health.Exchange).Set(v.health.Latency.Seconds())
+	requestErrorRate.WithLabelValues(v.health.Exchange).Set(v.health.ErrorRate)
+}
+
+// trip opens the breaker. Must be called with mu held.
+func (v *venueHealth) trip() {
+	v.health.OpenedAt = time.Now()
+	v.setState(BreakerOpen)
+	log.Printf("Circuit breaker for %s opened: %s", v.health.Exchange, v.health.LastError)
+}
+
+// reset closes the breaker and clears the failure window. Must be called
+// with mu held.
+func (v *venueHealth) reset() {
+	v.window = v.window[:0]
+	v.next = 0
+	v.probing = false
+	v.health.ConsecutiveFailures = 0
+	v.health.ErrorRate = 0
+	v.setState(BreakerClosed)
+	log.Printf("Circuit breaker for %s closed", v.health.Exchange)
+}
+
+func (v *venueHealth) setState(state BreakerState) {
+	v.health.Breaker = state
+	breakerState.WithLabelValues(v.health.Exchange).Set(float64(state))
+}
+
+func (v *venueHealth) updateStream(ev ConnectionEvent) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key := ev.Stream + ":" + ev.Symbol
+	if ev.State == Reconnecting {
+		v.streams[key] = ev.State
+	} else {
+		delete(v.streams, key)
+	}
+	v.health.StreamsDown = len(v.streams)
+	streamsDown.WithLabelValues(v.health.Exchange).Set(float64(v.health.StreamsDown))
+}
+
+func (v *venueHealth) export() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.setState(v.health.Breaker)
+}
+
+func (v *venueHealth) snapshot() VenueHealth {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.health
+}
+
+// isVenueFailure reports whether an error reflects on the venue's health.
+// Rejections caused by the order itself do not count, and neither do rate
+// limits unless the venue itself answered with HTTP 429 or 418.
+func isVenueFailure(err error) bool {
+	var rateLimited *RateLimitError
+	if errors.As(err, &rateLimited) {
+		return rateLimited.StatusCode != 0
+	}
+	return err != nil &&
+		!errors.Is(err, ErrInvalidOrder) &&
+		!errors.Is(err, ErrInsufficientFunds) &&
+		!errors.Is(err, ErrOrderNotFound) &&
+		!errors.Is(err, ErrNotSupported) &&
+		!errors.Is(err, ErrRateLimited) &&
+		!errors.Is(err, context.Canceled)
+}
+
+// venue returns the health tracker of an exchange, creating it on first use
+func (m *Manager) venue(name string) *venueHealth {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+
+	v, ok := m.health[name]
+	if !ok {
+		v = newVenueHealth(name, m.breakerConfig)
+		m.health[name] = v
+	}
+	return v
+}
+
+// BreakerConfig returns the breaker settings of the exchanges
+func (m *Manager) BreakerConfig() BreakerConfig {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	return m.breakerConfig
+}
+
+// SetBreakerConfig changes the breaker settings of all exchanges
+func (m *Manager) SetBreakerConfig(config BreakerConfig) {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+
+	m.breakerConfig = config
+	for _, v := range m.health {
+		v.mu.Lock()
+		v.config = config
+		v.mu.Unlock()
+	}
+}
+
+// call runs a request against an exchange through its circuit breaker and
+// records the outcome
+func (m *Manager) call(exchangeName string, fn func(Interface) error) error {
+	ex, release, ok := m.acquire(exchangeName)
+	if !ok {
+		return ErrExchangeNotFound
+	}
+	defer release()
+
+	v := m.venue(exchangeName)
+	if err := v.allow(); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	err := fn(ex)
+	v.record(time.Since(start), err)
+	return err
+}
+
+// CancelOrder cancels an order on the specified exchange
+func (m *Manager) CancelOrder(exchangeName, orderID string) error {
+	return m.call(exchangeName, func(ex Interface) error {
+		return ex.CancelOrder(orderID)
+	})
+}
+
+// GetOrderStatus returns the status of an order on the specified exchange
+func (m *Manager) GetOrderStatus(exchangeName, orderID string) (order.Status, error) {
+	status := order.Failed
+	err := m.call(exchangeName, func(ex Interface) error {
+		var err error
+		status, err = ex.GetOrderStatus(orderID)
+		return err
+	})
+	return status, err
+}
+
+// OrderTracker is implemented by exchanges that need to know an order's
+// symbol to query or cancel it, e.g. for orders placed before a restart
+type OrderTracker interface {
+	TrackOrder(orderID, symbol string)
+}
+
+// TrackOrder tells an exchange the symbol of an order it may not know.
+// Exchanges that look orders up by ID alone ignore it.
+func (m *Manager) TrackOrder(exchangeName, orderID, symbol string) {
+	ex, release, ok := m.acquire(exchangeName)
+	if !ok {
+		return
+	}
+	defer release()
+	if tracker, ok := ex.(OrderTracker); ok {
+		tracker.TrackOrder(orderID, symbol)
+	}
+}
+
+// Health returns a snapshot of every exchange's health
+func (m *Manager) Health() map[string]VenueHealth {
+	health := make(map[string]VenueHealth)
+	for _, name := range m.List() {
+		health[name] = m.venue(name).snapshot()
+	}
+	return health
+}
+
+// VenueHealth returns a snapshot of one exchange's health
+func (m *Manager) VenueHealth(exchangeName string) (VenueHealth, bool) {
+	if _, ok := m.GetExchange(exchangeName); !ok {
+		return VenueHealth{}, false
+	}
+	return m.venue(exchangeName).snapshot(), true
+}
+
+// TripBreaker opens an exchange's breaker manually, e.g. during venue
+// maintenance. It closes again after the open timeout unless the probe fails.
+func (m *Manager) TripBreaker(exchangeName, reason string) error {
+	if _, ok := m.GetExchange(exchangeName); !ok {
+		return ErrExchangeNotFound
+	}
+	v := m.venue(exchangeName)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.health.LastError = reason
+	v.trip()
+	return nil
+}
+
+// ResetBreaker closes an exchange's breaker manually
+func (m *Manager) ResetBreaker(exchangeName string) error {
+	if _, ok := m.GetExchange(exchangeName); !ok {
+		return ErrExchangeNotFound
+	}
+	v := m.venue(exchangeName)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.reset()
+	return nil
+}
+
+// MonitorHealth tracks stream connectivity from the exchanges' connection
+// events until the context is cancelled
+func (m *Manager) MonitorHealth(ctx context.Context) {
+	events := m.ConnectionEvents(ctx)
+	changes := m.Changes(ctx)
+
+	// Export the initial breaker state of every venue
+	for _, name := range m.List() {
+		m.venue(name).export()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-events:
+			m.venue(ev.Exchange).updateStream(ev)
+		case ev, ok := <-changes:
+			if !ok {
+				return
+			}
+			// A venue that comes back starts with a clean slate
+			m.healthMu.Lock()
+			delete(m.health, ev.Exchange)
+			m.healthMu.Unlock()
+			if ev.Type == ExchangeAdded {
+				m.venue(ev.Exchange).export()
+			}
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package exchange
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBreakerTransitions(t *testing.T) {
+	v := newVenueHealth("test", BreakerConfig{
+		ConsecutiveFailures: 3,
+		ErrorRate:           1,
+		MinRequests:         100,
+		Window:              10,
+		OpenTimeout:         20 * time.Millisecond,
+	})
+	failure := errors.New("connection reset")
+
+	// Rejections of the request itself are not venue failures
+	for i := 0; i < 5; i++ {
+		v.record(time.Millisecond, ErrInsufficientFunds)
+	}
+	if v.snapshot().Breaker != BreakerClosed {
+		t.Fatal("breaker tripped on request errors")
+	}
+
+	for i := 0; i < 2; i++ {
+		v.record(time.Millisecond, failure)
+	}
+	if v.snapshot().Breaker != BreakerClosed {
+		t.Fatal("breaker tripped before the failure threshold")
+	}
+	v.record(time.Millisecond, failure)
+	if v.snapshot().Breaker != BreakerOpen {
+		t.Fatal("breaker not open after consecutive failures")
+	}
+	if err := v.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("allow = %v, want ErrCircuitOpen", err)
+	}
+
+	// After the timeout a single probe goes through
+	time.Sleep(25 * time.Millisecond)
+	if err := v.allow(); err != nil {
+		t.Fatalf("probe refused: %v", err)
+	}
+	if v.snapshot().Breaker != BreakerHalfOpen {
+		t.Fatal("breaker not half-open while probing")
+	}
+	if err := v.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("second probe allowed: %v", err)
+	}
+
+	// A failed probe opens it again, a successful one closes it
+	v.record(time.Millisecond, failure)
+	if v.snapshot().Breaker != BreakerOpen {
+		t.Fatal("breaker not reopened after a failed probe")
+	}
+	time.Sleep(25 * time.Millisecond)
+	if err := v.allow(); err != nil {
+		t.Fatalf("probe refused: %v", err)
+	}
+	v.record(time.Millisecond, nil)
+	h := v.snapshot()
+	if h.Breaker != BreakerClosed || h.ConsecutiveFailures != 0 || h.ErrorRate != 0 {
+		t.Errorf("after a successful probe: %+v", h)
+	}
+}
+
+func TestBreakerErrorRate(t *testing.T) {
+	v := newVenueHealth("test", BreakerConfig{
+		ConsecutiveFailures: 100,
+		ErrorRate:           0.5,
+		MinRequests:         4,
+		Window:              4,
+		OpenTimeout:         time.Minute,
+	})
+	failure := errors.New("HTTP 502")
+
+	// Half of the window failing trips it once enough requests were seen
+	v.record(time.Millisecond, failure)
+	v.record(time.Millisecond, nil)
+	v.record(time.Millisecond, failure)
+	if v.snapshot().Breaker != BreakerClosed {
+		t.Fatal("breaker tripped below MinRequests")
+	}
+	v.record(time.Millisecond, nil)
+	if v.snapshot().Breaker != BreakerClosed {
+		t.Fatal("breaker tripped on a success")
+	}
+	v.record(time.Millisecond, failure)
+	if h := v.snapshot(); h.Breaker != BreakerOpen || h.ErrorRate != 0.5 {
+		t.Errorf("breaker %v at error rate %v, want open at 0.5", h.Breaker, h.ErrorRate)
+	}
+
+	// Venue-issued rate limits count, ones the limiter raised do not
+	if isVenueFailure(&RateLimitError{Exchange: "test"}) {
+		t.Error("local rate limit counted as a venue failure")
+	}
+	if !isVenueFailure(&RateLimitError{Exchange: "test", StatusCode: 429}) {
+		t.Error("venue 429 not counted as a failure")
+	}
+}
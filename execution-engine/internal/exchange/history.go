@@ -0,0 +1,125 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/trading-system/execution-engine/internal/order"
+)
+
+// Kline is an OHLCV candle
+type Kline struct {
+	Symbol   string
+	Interval time.Duration
+	OpenTime time.Time
+	Open     float64
+	High     float64
+	Low      float64
+	Close    float64
+	Volume   float64 // base asset
+	Trades   int64   // zero if the venue does not report it
+}
+
+// HistoricalTrade is a past public trade. On Binance this is an aggregate
+// of fills from the same taker order at the same price.
+type HistoricalTrade struct {
+	ID        string
+	Symbol    string
+	Price     float64
+	Quantity  float64
+	Side      order.Side // taker side
+	Timestamp time.Time
+}
+
+// HistoryProvider is implemented by exchanges that serve historical market
+// data. Each call returns one page starting at start, oldest first, along
+// with the time the next page starts; next is at or after end once the
+// range is exhausted.
+type HistoryProvider interface {
+	GetKlines(ctx context.Context, symbol string, interval time.Duration, start, end time.Time) (klines []Kline, next time.Time, err error)
+	GetHistoricalTrades(ctx context.Context, symbol string, start, end time.Time) (trades []HistoricalTrade, next time.Time, err error)
+}
+
+func (m *Manager) history(exchangeName string) (HistoryProvider, error) {
+	ex, ok := m.GetExchange(exchangeName)
+	if !ok {
+		return nil, ErrExchangeNotFound
+	}
+	provider, ok := ex.(HistoryProvider)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return provider, nil
+}
+
+// FetchKlines pages through the klines of a symbol between start and end,
+// passing each page to fn
+func (m *Manager) FetchKlines(ctx context.Context, exchangeName, symbol string, interval time.Duration, start, end time.Time, fn func([]Kline) error) error {
+	provider, err := m.history(exchangeName)
+	if err != nil {
+		return err
+	}
+	return paginate(ctx, start, end, func(from time.Time) (time.Time, error) {
+		klines, next, err := provider.GetKlines(ctx, symbol, interval, from, end)
+		if err != nil {
+			return from, err
+		}
+		if len(klines) > 0 {
+			if err := fn(klines); err != nil {
+				return from, err
+			}
+		}
+		return next, nil
+	})
+}
+
+// FetchTrades pages through the public trades of a symbol between start and
+// end, passing each page to fn
+func (m *Manager) FetchTrades(ctx context.Context, exchangeName, symbol string, start, end time.Time, fn func([]HistoricalTrade) error) error {
+	provider, err := m.history(exchangeName)
+	if err != nil {
+		return err
+	}
+	return paginate(ctx, start, end, func(from time.Time) (time.Time, error) {
+		trades, next, err := provider.GetHistoricalTrades(ctx, symbol, from, end)
+		if err != nil {
+			return from, err
+		}
+		if len(trades) > 0 {
+			if err := fn(trades); err != nil {
+				return from, err
+			}
+		}
+		return next, nil
+	})
+}
+
+// paginate requests pages until the range is exhausted. Requests already
+// wait on the venue's rate limiter; when it gives up, the page is retried
+// once the venue allows it.
+func paginate(ctx context.Context, start, end time.Time, page func(from time.Time) (time.Time, error)) error {
+	for start.Before(end) {
+		next, err := page(start)
+		var rateLimited *RateLimitError
+		if errors.As(err, &rateLimited) {
+			timer := time.NewTimer(rateLimited.RetryAfter)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !next.After(start) {
+			return fmt.Errorf("history page did not advance past %v", start)
+		}
+		start = next
+	}
+	return nil
+}
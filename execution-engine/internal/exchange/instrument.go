@@ -0,0 +1,184 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/trading-system/execution-engine/internal/order"
+)
+
+// Instrument holds the trading rules a venue enforces for a symbol
+type Instrument struct {
+	Symbol      string
+	BaseAsset   string
+	QuoteAsset  string
+	TickSize    float64 // price increment
+	StepSize    float64 // quantity increment
+	MinQuantity float64
+	MaxQuantity float64 // zero means unlimited
+	MinNotional float64 // minimum price * quantity
+}
+
+// InstrumentProvider is implemented by exchanges that publish trading rules
+type InstrumentProvider interface {
+	GetInstruments(ctx context.Context) ([]Instrument, error)
+}
+
+// Normalize rounds an order's price and quantity onto the instrument's grid
+// and validates it against the quantity and notional limits. Prices are
+// rounded away from the market (down for buys, up for sells) so the order
+// is never more aggressive than requested.
+func (i Instrument) Normalize(o *order.Order) error {
+	o.Quantity = roundToStep(o.Quantity, i.StepSize, false)
+	if o.Type == order.Limit {
+		o.Price = roundToStep(o.Price, i.TickSize, o.Side == order.Sell)
+		if o.Price <= 0 {
+			return fmt.Errorf("%w: price rounds to zero with tick size %v", ErrInvalidOrder, i.TickSize)
+		}
+	}
+
+	if o.Quantity <= 0 || o.Quantity < i.MinQuantity {
+		return fmt.Errorf("%w: quantity %v below minimum %v", ErrInvalidOrder, o.Quantity, i.MinQuantity)
+	}
+	if i.MaxQuantity > 0 && o.Quantity > i.MaxQuantity {
+		return fmt.Errorf("%w: quantity %v above maximum %v", ErrInvalidOrder, o.Quantity, i.MaxQuantity)
+	}
+	// Market orders carry no price, the venue checks their notional itself
+	if o.Price > 0 && o.Price*o.Quantity < i.MinNotional {
+		return fmt.Errorf("%w: notional %v below minimum %v", ErrInvalidOrder, o.Price*o.Quantity, i.MinNotional)
+	}
+	return nil
+}
+
+// roundToStep rounds value down (or up) to a multiple of step and trims
+// floating point noise to the step's decimal places
+func roundToStep(value, step float64, up bool) float64 {
+	if step <= 0 {
+		return value
+	}
+
+	// The epsilon keeps exact multiples from being pushed a whole step away
+	units := value / step
+	if up {
+		units = math.Ceil(units - 1e-9)
+	} else {
+		units = math.Floor(units + 1e-9)
+	}
+	rounded := units * step
+
+	decimals := 0
+	if s := strconv.FormatFloat(step, 'f', -1, 64); strings.Contains(s, ".") {
+		decimals = len(s) - strings.Index(s, ".") - 1
+	}
+	rounded, _ = strconv.ParseFloat(strconv.FormatFloat(rounded, 'f', decimals, 64), 64)
+	return rounded
+}
+
+// InstrumentRegistry caches instruments per exchange, keyed by native symbol
+type InstrumentRegistry struct {
+	instruments map[string]map[string]Instrument
+	updatedAt   map[string]time.Time
+	mu          sync.RWMutex
+}
+
+// NewInstrumentRegistry creates an empty instrument registry
+func NewInstrumentRegistry() *InstrumentRegistry {
+	return &InstrumentRegistry{
+		instruments: make(map[string]map[string]Instrument),
+		updatedAt:   make(map[string]time.Time),
+	}
+}
+
+// Load replaces the instruments known for an exchange
+func (r *InstrumentRegistry) Load(exchangeName string, instruments []Instrument) {
+	bySymbol := make(map[string]Instrument, len(instruments))
+	for _, inst := range instruments {
+		bySymbol[inst.Symbol] = inst
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.instruments[exchangeName] = bySymbol
+	r.updatedAt[exchangeName] = time.Now()
+}
+
+// Get returns the instrument for a symbol on an exchange
+func (r *InstrumentRegistry) Get(exchangeName, symbol string) (Instrument, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	inst, ok := r.instruments[exchangeName][symbol]
+	return inst, ok
+}
+
+// UpdatedAt returns when an exchange's instruments were last loaded
+func (r *InstrumentRegistry) UpdatedAt(exchangeName string) time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.updatedAt[exchangeName]
+}
+
+// Instruments returns the manager's instrument registry
+func (m *Manager) Instruments() *InstrumentRegistry {
+	return m.instruments
+}
+
+// GetInstrument returns the trading rules for a canonical or native symbol
+// on an exchange
+func (m *Manager) GetInstrument(exchangeName, symbol string) (Instrument, bool) {
+	return m.instruments.Get(exchangeName, m.NativeSymbol(exchangeName, symbol))
+}
+
+// RefreshInstruments reloads instruments from every exchange that provides them
+func (m *Manager) RefreshInstruments(ctx context.Context) error {
+	var errs []error
+	for name, ex := range m.GetAllExchanges() {
+		provider, ok := ex.(InstrumentProvider)
+		if !ok {
+			continue
+		}
+		instruments, err := provider.GetInstruments(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		m.instruments.Load(name, instruments)
+	}
+	return errors.Join(errs...)
+}
+
+// RunInstrumentRefresh loads instruments immediately, whenever an exchange
+// is registered and then at regular intervals
+func (m *Manager) RunInstrumentRefresh(ctx context.Context, interval time.Duration) {
+	changes := m.Changes(ctx)
+	if err := m.RefreshInstruments(ctx); err != nil {
+		log.Printf("Instrument refresh failed: %v", err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.RefreshInstruments(ctx); err != nil {
+				log.Printf("Instrument refresh failed: %v", err)
+			}
+		case ev, ok := <-changes:
+			if !ok || ev.Type != ExchangeAdded {
+				continue
+			}
+			if err := m.RefreshInstruments(ctx); err != nil {
+				log.Printf("Instrument refresh failed: %v", err)
+			}
+		}
+	}
+}
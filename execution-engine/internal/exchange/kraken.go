@@ -0,0 +1,1588 @@
+package exchange
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/trading-system/execution-engine/internal/order"
+)
+
+const (
+	krakenRESTURL   = "https://api.kraken.com"
+	krakenWSURL     = "wss://ws.kraken.com"
+	krakenWSAuthURL = "wss://ws-auth.kraken.com"
+)
+
+// krakenAssets maps common currency codes onto Kraken's legacy asset names
+var krakenAssets = map[string][]string{
+	"BTC":  {"XXBT", "XBT"},
+	"ETH":  {"XETH"},
+	"USD":  {"ZUSD"},
+	"EUR":  {"ZEUR"},
+	"USDT": {"USDT"},
+}
+
+// krakenSymbolAliases maps common asset codes onto the names Kraken uses in pairs
+var krakenSymbolAliases = map[string]string{
+	"BTC":  "XBT",
+	"DOGE": "XDG",
+}
+
+// KrakenClient implements the exchange interface for Kraken
+type KrakenClient struct {
+	apiKey      string
+	apiSecret   string
+	restURL     string
+	wsURL       string
+	wsAuthURL   string
+	httpClient  *http.Client
+	limiter     *RateLimiter
+	nonceMutex  sync.Mutex
+	lastNonce   int64
+	streams     map[string]*krakenTradeStream
+	streamMutex sync.Mutex
+	events      connectionEvents
+	connected   bool
+}
+
+// krakenTradeStream is a trade subscription that survives reconnects
+type krakenTradeStream struct {
+	events chan TradeEvent
+	cancel context.CancelFunc
+}
+
+// errKrakenSubscription is returned by readers when Kraken rejects a
+// subscription, which resubscribing would not fix
+var errKrakenSubscription = errors.New("kraken: subscription rejected")
+
+// NewKrakenClient creates a new Kraken client
+func NewKrakenClient() *KrakenClient {
+	limiter := NewRateLimiter("kraken", krakenRateLimits...)
+	return &KrakenClient{
+		restURL:    krakenRESTURL,
+		wsURL:      krakenWSURL,
+		wsAuthURL:  krakenWSAuthURL,
+		httpClient: newRateLimitedClient(limiter, krakenRequestCosts, nil),
+		limiter:    limiter,
+		streams:    make(map[string]*krakenTradeStream),
+	}
+}
+
+// krakenRateLimits model the Starter tier call counters: the REST counter
+// allows 15 and decays by 0.33/s, the trading counter allows 60 and decays
+// by 1/s, and public endpoints allow about one call per second
+var krakenRateLimits = []RateLimit{
+	{Class: ClassRequests, Limit: 15, Interval: 45 * time.Second},
+	{Class: ClassOrders, Limit: 60, Interval: time.Minute},
+	{Class: ClassPublic, Limit: 1, Interval: time.Second},
+}
+
+// krakenRequestCosts classifies a REST call by endpoint. Trading calls use
+// their own counter and do not count against the REST counter.
+func krakenRequestCosts(req *http.Request) []Cost {
+	path := req.URL.Path
+	switch {
+	case strings.HasPrefix(path, "/0/public/"):
+		return []Cost{{ClassPublic, 1}}
+	case strings.HasSuffix(path, "/AddOrder"), strings.HasSuffix(path, "/CancelOrder"),
+		strings.HasSuffix(path, "/EditOrder"), strings.HasSuffix(path, "/AmendOrder"), strings.HasSuffix(path, "/CancelAll"):
+		return []Cost{{ClassOrders, 1}}
+	case strings.HasSuffix(path, "/Ledgers"), strings.HasSuffix(path, "/QueryLedgers"),
+		strings.HasSuffix(path, "/TradesHistory"), strings.HasSuffix(path, "/QueryTrades"):
+		return []Cost{{ClassRequests, 2}}
+	}
+	return []Cost{{ClassRequests, 1}}
+}
+
+// RateLimiter returns the limiter shared by all Kraken REST calls
+func (k *KrakenClient) RateLimiter() *RateLimiter {
+	return k.limiter
+}
+
+// SetCredentials sets the API key and base64 encoded private key
+func (k *KrakenClient) SetCredentials(apiKey, apiSecret string) {
+	k.apiKey = apiKey
+	k.apiSecret = apiSecret
+}
+
+// SetEndpoints overrides the REST, public websocket and private websocket URLs
+func (k *KrakenClient) SetEndpoints(restURL, wsURL, wsAuthURL string) {
+	k.restURL = strings.TrimRight(restURL, "/")
+	k.wsURL = wsURL
+	k.wsAuthURL = wsAuthURL
+}
+
+// krakenEndpoints are the spot deployments. Kraken has no spot sandbox, so
+// staging must point the adapter at custom endpoints.
+var krakenEndpoints = map[Environment]Endpoints{
+	Production: {REST: krakenRESTURL, Websocket: krakenWSURL, PrivateWebsocket: krakenWSAuthURL},
+}
+
+// Configure selects the deployment and sets the API credentials
+func (k *KrakenClient) Configure(cfg EnvironmentConfig) error {
+	endpoints, err := resolveEndpoints("kraken", krakenEndpoints, cfg)
+	if err != nil {
+		return err
+	}
+	k.SetCredentials(cfg.APIKey, cfg.APISecret)
+	k.SetEndpoints(endpoints.REST, endpoints.Websocket, endpoints.PrivateWebsocket)
+	return nil
+}
+
+// Connect establishes connection to Kraken
+func (k *KrakenClient) Connect() error {
+	// Test connectivity
+	var res struct {
+		UnixTime int64 `json:"unixtime"`
+	}
+	if err := k.publicRequest(context.Background(), "/0/public/Time", nil, &res); err != nil {
+		return err
+	}
+	k.connected = true
+	log.Println("Connected to Kraken")
+	return nil
+}
+
+// Disconnect closes all connections
+func (k *KrakenClient) Disconnect() error {
+	k.streamMutex.Lock()
+	defer k.streamMutex.Unlock()
+
+	// Each stream closes its channel once its socket has shut down
+	for symbol, s := range k.streams {
+		s.cancel()
+		delete(k.streams, symbol)
+	}
+	k.connected = false
+	return nil
+}
+
+// PlaceOrder places an order on Kraken
+func (k *KrakenClient) PlaceOrder(ctx context.Context, o *order.Order) (string, error) {
+	if !k.connected {
+		return "", ErrNotConnected
+	}
+	if err := checkSpotOrder(o); err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("pair", krakenRESTPair(nativeSymbol(k, o.Symbol)))
+	params.Set("volume", o.QuantityString())
+
+	if o.Side == order.Sell {
+		params.Set("type", "sell")
+	} else {
+		params.Set("type", "buy")
+	}
+
+	if o.Type == order.Market {
+		params.Set("ordertype", "market")
+	} else {
+		params.Set("ordertype", "limit")
+		params.Set("price", o.PriceString())
+	}
+
+	var res struct {
+		TxID []string `json:"txid"`
+	}
+	if err := k.privateRequest(ctx, "/0/private/AddOrder", params, &res); err != nil {
+		return "", err
+	}
+	if len(res.TxID) == 0 {
+		return "", errors.New("kraken: order accepted without txid")
+	}
+	return res.TxID[0], nil
+}
+
+// CancelOrder cancels an open order on Kraken
+func (k *KrakenClient) CancelOrder(orderID string) error {
+	if !k.connected {
+		return ErrNotConnected
+	}
+
+	params := url.Values{}
+	params.Set("txid", orderID)
+
+	var res struct {
+		Count int `json:"count"`
+	}
+	if err := k.privateRequest(context.Background(), "/0/private/CancelOrder", params, &res); err != nil {
+		return err
+	}
+	if res.Count == 0 {
+		return fmt.Errorf("kraken: order %s was not cancelled: %w", orderID, ErrOrderNotFound)
+	}
+	return nil
+}
+
+// ModifyOrder amends the price and quantity of a working limit order in
+// place. Kraken keeps the txid and the order's queue priority unless the
+// price changes or the quantity grows.
+func (k *KrakenClient) ModifyOrder(ctx context.Context, o *order.Order, price, quantity float64) (string, error) {
+	if !k.connected {
+		return "", ErrNotConnected
+	}
+
+	params := url.Values{}
+	params.Set("txid", o.ID)
+	params.Set("order_qty", strconv.FormatFloat(quantity, 'f', -1, 64))
+	params.Set("limit_price", strconv.FormatFloat(price, 'f', -1, 64))
+
+	if err := k.privateRequest(ctx, "/0/private/AmendOrder", params, nil); err != nil {
+		return "", err
+	}
+	return o.ID, nil
+}
+
+// CancelAllAfter restarts Kraken's countdown, after which it cancels all
+// open orders. Kraken counts in whole seconds.
+func (k *KrakenClient) CancelAllAfter(ctx context.Context, timeout time.Duration) error {
+	if !k.connected {
+		return ErrNotConnected
+	}
+
+	params := url.Values{}
+	params.Set("timeout", strconv.FormatInt(int64(math.Ceil(timeout.Seconds())), 10))
+	return k.privateRequest(ctx, "/0/private/CancelAllOrdersAfter", params, nil)
+}
+
+// GetOrderStatus returns the current status of an order on Kraken
+func (k *KrakenClient) GetOrderStatus(orderID string) (order.Status, error) {
+	if !k.connected {
+		return order.Failed, ErrNotConnected
+	}
+
+	params := url.Values{}
+	params.Set("txid", orderID)
+
+	var res map[string]krakenOrderInfo
+	if err := k.privateRequest(context.Background(), "/0/private/QueryOrders", params, &res); err != nil {
+		return order.Failed, err
+	}
+
+	info, ok := res[orderID]
+	if !ok {
+		return order.Failed, fmt.Errorf("kraken: order %s: %w", orderID, ErrOrderNotFound)
+	}
+	return info.status(), nil
+}
+
+// GetBalance returns the balance held in a currency on Kraken
+func (k *KrakenClient) GetBalance(currency string) (float64, error) {
+	if !k.connected {
+		return 0, ErrNotConnected
+	}
+
+	var res map[string]string
+	if err := k.privateRequest(context.Background(), "/0/private/Balance", nil, &res); err != nil {
+		return 0, err
+	}
+
+	currency = strings.ToUpper(currency)
+	for _, asset := range append([]string{currency}, krakenAssets[currency]...) {
+		if amount, ok := res[asset]; ok {
+			return strconv.ParseFloat(amount, 64)
+		}
+	}
+	return 0, nil
+}
+
+// GetBalances returns every asset with its balance and the amount held by
+// open orders
+func (k *KrakenClient) GetBalances(ctx context.Context) ([]Balance, error) {
+	if !k.connected {
+		return nil, ErrNotConnected
+	}
+
+	var res map[string]struct {
+		Balance   string `json:"balance"`
+		HoldTrade string `json:"hold_trade"`
+	}
+	if err := k.privateRequest(ctx, "/0/private/BalanceEx", nil, &res); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var balances []Balance
+	for code, bal := range res {
+		total, _ := strconv.ParseFloat(bal.Balance, 64)
+		hold, _ := strconv.ParseFloat(bal.HoldTrade, 64)
+		if total == 0 {
+			continue
+		}
+		balances = append(balances, Balance{
+			Asset:     krakenAssetName(code),
+			Free:      total - hold,
+			Locked:    hold,
+			Total:     total,
+			Timestamp: now,
+		})
+	}
+	return balances, nil
+}
+
+// krakenAssetName converts a Kraken asset code such as XXBT or ZUSD into
+// its common name
+func krakenAssetName(code string) string {
+	for asset, codes := range krakenAssets {
+		for _, c := range codes {
+			if c == code {
+				return asset
+			}
+		}
+	}
+	if len(code) == 4 && (code[0] == 'X' || code[0] == 'Z') {
+		code = code[1:]
+	}
+	return canonicalAsset(code)
+}
+
+// GetFees returns the account's fee tier for a pair. Kraken quotes fees in
+// percent.
+func (k *KrakenClient) GetFees(ctx context.Context, symbol string) (*FeeSchedule, error) {
+	if !k.connected {
+		return nil, ErrNotConnected
+	}
+
+	type tier struct {
+		Fee string `json:"fee"`
+	}
+	var res struct {
+		Fees      map[string]tier `json:"fees"`
+		FeesMaker map[string]tier `json:"fees_maker"`
+	}
+	params := url.Values{"pair": {krakenRESTPair(nativeSymbol(k, symbol))}}
+	if err := k.privateRequest(ctx, "/0/private/TradeVolume", params, &res); err != nil {
+		return nil, err
+	}
+
+	if len(res.Fees) == 0 {
+		return nil, fmt.Errorf("kraken: no fee tier for %s", symbol)
+	}
+
+	// Results are keyed by Kraken's own pair name, which may differ from
+	// the one requested. Pairs without a maker discount omit fees_maker.
+	fees := &FeeSchedule{}
+	for _, t := range res.Fees {
+		taker, _ := strconv.ParseFloat(t.Fee, 64)
+		fees.Taker = taker / 100
+	}
+	fees.Maker = fees.Taker
+	for _, t := range res.FeesMaker {
+		maker, _ := strconv.ParseFloat(t.Fee, 64)
+		fees.Maker = maker / 100
+	}
+	return fees, nil
+}
+
+// krakenFundingAsset returns the asset name used by the funding endpoints
+func krakenFundingAsset(asset string) string {
+	asset = strings.ToUpper(asset)
+	if alias, ok := krakenSymbolAliases[asset]; ok {
+		return alias
+	}
+	return asset
+}
+
+// krakenTransfer is a row of the WithdrawStatus and DepositStatus endpoints
+type krakenTransfer struct {
+	Method     string `json:"method"`
+	Asset      string `json:"asset"`
+	RefID      string `json:"refid"`
+	TxID       string `json:"txid"`
+	Info       string `json:"info"`
+	Amount     string `json:"amount"`
+	Fee        string `json:"fee"`
+	Time       int64  `json:"time"`
+	Status     string `json:"status"`
+	StatusProp string `json:"status-prop"`
+}
+
+func (t krakenTransfer) transfer() Transfer {
+	amount, _ := strconv.ParseFloat(t.Amount, 64)
+	fee, _ := strconv.ParseFloat(t.Fee, 64)
+
+	status := TransferPending
+	switch {
+	case t.StatusProp == "canceled" || t.StatusProp == "return":
+		status = TransferCancelled
+	case t.Status == "Success":
+		status = TransferCompleted
+	case t.Status == "Failure":
+		status = TransferFailed
+	case t.Status == "Settled" || t.TxID != "":
+		status = TransferProcessing
+	}
+
+	return Transfer{
+		ID:        t.RefID,
+		Asset:     krakenAssetName(t.Asset),
+		Network:   t.Method,
+		Address:   t.Info,
+		Amount:    amount,
+		Fee:       fee,
+		TxID:      t.TxID,
+		Status:    status,
+		Timestamp: time.Unix(t.Time, 0),
+	}
+}
+
+// GetDepositAddress returns a deposit address of an asset. Kraken calls
+// networks funding methods; an empty network selects the first method.
+func (k *KrakenClient) GetDepositAddress(ctx context.Context, asset, network string) (*DepositAddress, error) {
+	if !k.connected {
+		return nil, ErrNotConnected
+	}
+
+	params := url.Values{"asset": {krakenFundingAsset(asset)}}
+	if network == "" {
+		var methods []struct {
+			Method string `json:"method"`
+		}
+		if err := k.privateRequest(ctx, "/0/private/DepositMethods", params, &methods); err != nil {
+			return nil, err
+		}
+		if len(methods) == 0 {
+			return nil, fmt.Errorf("kraken: no deposit methods for %s: %w", asset, ErrNotSupported)
+		}
+		network = methods[0].Method
+	}
+
+	params = url.Values{"asset": {krakenFundingAsset(asset)}, "method": {network}}
+	var addresses []struct {
+		Address string `json:"address"`
+		Tag     string `json:"tag"`
+		Memo    string `json:"memo"`
+	}
+	if err := k.privateRequest(ctx, "/0/private/DepositAddresses", params, &addresses); err != nil {
+		return nil, err
+	}
+	if len(addresses) == 0 {
+		// Generate an address the first time the method is used
+		params = url.Values{"asset": {krakenFundingAsset(asset)}, "method": {network}, "new": {"true"}}
+		if err := k.privateRequest(ctx, "/0/private/DepositAddresses", params, &addresses); err != nil {
+			return nil, err
+		}
+		if len(addresses) == 0 {
+			return nil, fmt.Errorf("kraken: no deposit address for %s via %s", asset, network)
+		}
+	}
+
+	tag := addresses[0].Tag
+	if tag == "" {
+		tag = addresses[0].Memo
+	}
+	return &DepositAddress{
+		Asset:   strings.ToUpper(asset),
+		Network: network,
+		Address: addresses[0].Address,
+		Tag:     tag,
+	}, nil
+}
+
+// Withdraw submits a withdrawal to a pre-approved address. Kraken only
+// withdraws to addresses configured in the account, identified by Key; if
+// Address is also set Kraken checks that it matches the key.
+func (k *KrakenClient) Withdraw(ctx context.Context, w Withdrawal) (string, error) {
+	if !k.connected {
+		return "", ErrNotConnected
+	}
+	if w.Key == "" {
+		return "", fmt.Errorf("%w: kraken withdrawals require an address key", ErrInvalidTransfer)
+	}
+
+	params := url.Values{
+		"asset":  {krakenFundingAsset(w.Asset)},
+		"key":    {w.Key},
+		"amount": {strconv.FormatFloat(w.Amount, 'f', -1, 64)},
+	}
+	if w.Address != "" {
+		params.Set("address", w.Address)
+	}
+
+	var res struct {
+		RefID string `json:"refid"`
+	}
+	if err := k.privateRequest(ctx, "/0/private/Withdraw", params, &res); err != nil {
+		return "", err
+	}
+	return res.RefID, nil
+}
+
+// GetWithdrawal returns a recent withdrawal by reference ID
+func (k *KrakenClient) GetWithdrawal(ctx context.Context, asset, id string) (*Transfer, error) {
+	if !k.connected {
+		return nil, ErrNotConnected
+	}
+
+	var res []krakenTransfer
+	params := url.Values{"asset": {krakenFundingAsset(asset)}}
+	if err := k.privateRequest(ctx, "/0/private/WithdrawStatus", params, &res); err != nil {
+		return nil, err
+	}
+	for _, t := range res {
+		if t.RefID == id {
+			transfer := t.transfer()
+			return &transfer, nil
+		}
+	}
+	return nil, ErrTransferNotFound
+}
+
+// GetDeposits returns recent deposits of an asset since the given time
+func (k *KrakenClient) GetDeposits(ctx context.Context, asset string, since time.Time) ([]Transfer, error) {
+	if !k.connected {
+		return nil, ErrNotConnected
+	}
+
+	var res []krakenTransfer
+	params := url.Values{
+		"asset": {krakenFundingAsset(asset)},
+		"start": {strconv.FormatInt(since.Unix(), 10)},
+	}
+	if err := k.privateRequest(ctx, "/0/private/DepositStatus", params, &res); err != nil {
+		return nil, err
+	}
+
+	deposits := make([]Transfer, 0, len(res))
+	for _, t := range res {
+		deposits = append(deposits, t.transfer())
+	}
+	return deposits, nil
+}
+
+// StreamTrades opens a real-time trade stream for a symbol
+func (k *KrakenClient) StreamTrades(ctx context.Context, symbol string) (chan TradeEvent, error) {
+	if !k.connected {
+		return nil, ErrNotConnected
+	}
+
+	k.streamMutex.Lock()
+	defer k.streamMutex.Unlock()
+
+	if s, exists := k.streams[symbol]; exists {
+		return s.events, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	conn, err := k.subscribeTrades(ctx, symbol)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	s := &krakenTradeStream{
+		events: make(chan TradeEvent, 100),
+		cancel: cancel,
+	}
+	k.streams[symbol] = s
+	k.events.publish(ConnectionEvent{Stream: "trades", Symbol: symbol, State: Connected})
+
+	go k.maintainTrades(ctx, symbol, s, conn)
+	return s.events, nil
+}
+
+func (k *KrakenClient) subscribeTrades(ctx context.Context, symbol string) (*websocket.Conn, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, k.wsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	subscribe := map[string]interface{}{
+		"event":        "subscribe",
+		"pair":         []string{nativeSymbol(k, symbol)},
+		"subscription": map[string]string{"name": "trade"},
+	}
+	if err := conn.WriteJSON(subscribe); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// maintainTrades reads a trade stream and resubscribes with backoff
+// whenever its socket drops, until the context is cancelled
+func (k *KrakenClient) maintainTrades(ctx context.Context, symbol string, s *krakenTradeStream, conn *websocket.Conn) {
+	defer func() {
+		k.streamMutex.Lock()
+		if k.streams[symbol] == s {
+			delete(k.streams, symbol)
+		}
+		k.streamMutex.Unlock()
+
+		close(s.events)
+		k.events.publish(ConnectionEvent{Stream: "trades", Symbol: symbol, State: Disconnected})
+	}()
+
+	for {
+		err := k.readTrades(ctx, symbol, conn, s.events)
+		conn.Close()
+		if ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, errKrakenSubscription) {
+			log.Printf("Kraken trade stream for %s closed: %v", symbol, err)
+			return
+		}
+		log.Printf("Kraken trade stream for %s dropped, reconnecting: %v", symbol, err)
+
+		for attempt := 0; ; attempt++ {
+			k.events.publish(ConnectionEvent{Stream: "trades", Symbol: symbol, State: Reconnecting, Attempt: attempt + 1, Err: err})
+			if !backoff(ctx, attempt) {
+				return
+			}
+			if conn, err = k.subscribeTrades(ctx, symbol); err == nil {
+				break
+			}
+			log.Printf("Kraken trade stream reconnect for %s failed: %v", symbol, err)
+		}
+		k.events.publish(ConnectionEvent{Stream: "trades", Symbol: symbol, State: Connected})
+	}
+}
+
+// ConnectionEvents reports state changes of the trade and book streams
+func (k *KrakenClient) ConnectionEvents(ctx context.Context) <-chan ConnectionEvent {
+	return k.events.subscribe(ctx)
+}
+
+// GetInstruments loads trading rules for all pairs from AssetPairs. Pairs
+// are keyed by their websocket name (XBT/USDT), matching StreamTrades.
+func (k *KrakenClient) GetInstruments(ctx context.Context) ([]Instrument, error) {
+	var res map[string]struct {
+		WSName       string `json:"wsname"`
+		LotDecimals  int    `json:"lot_decimals"`
+		PairDecimals int    `json:"pair_decimals"`
+		OrderMin     string `json:"ordermin"`
+		CostMin      string `json:"costmin"`
+		TickSize     string `json:"tick_size"`
+	}
+	if err := k.publicRequest(ctx, "/0/public/AssetPairs", nil, &res); err != nil {
+		return nil, err
+	}
+
+	instruments := make([]Instrument, 0, len(res))
+	for _, pair := range res {
+		if pair.WSName == "" {
+			continue
+		}
+		base, quote := splitSymbol(pair.WSName)
+		inst := Instrument{
+			Symbol:     pair.WSName,
+			BaseAsset:  base,
+			QuoteAsset: quote,
+			TickSize:   math.Pow10(-pair.PairDecimals),
+			StepSize:   math.Pow10(-pair.LotDecimals),
+		}
+		if pair.TickSize != "" {
+			inst.TickSize, _ = strconv.ParseFloat(pair.TickSize, 64)
+		}
+		inst.MinQuantity, _ = strconv.ParseFloat(pair.OrderMin, 64)
+		inst.MinNotional, _ = strconv.ParseFloat(pair.CostMin, 64)
+		instruments = append(instruments, inst)
+	}
+	return instruments, nil
+}
+
+// readTrades forwards trades from a socket until it fails or the context
+// is cancelled
+func (k *KrakenClient) readTrades(ctx context.Context, symbol string, conn *websocket.Conn, ch chan TradeEvent) error {
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+
+		// Events (heartbeat, subscriptionStatus) are objects, channel data are arrays
+		if len(data) == 0 || data[0] != '[' {
+			var event struct {
+				Event        string `json:"event"`
+				Status       string `json:"status"`
+				ErrorMessage string `json:"errorMessage"`
+			}
+			if err := json.Unmarshal(data, &event); err == nil && event.Status == "error" {
+				return fmt.Errorf("%w: %s", errKrakenSubscription, event.ErrorMessage)
+			}
+			continue
+		}
+
+		trades, err := parseKrakenTrades(data)
+		if err != nil {
+			log.Printf("Kraken stream decode error: %v", err)
+			continue
+		}
+		for _, t := range trades {
+			// Report trades under the symbol the caller subscribed with
+			t.Symbol = symbol
+			select {
+			case ch <- t:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// parseKrakenTrades decodes a trade channel message of the form
+// [channelID, [[price, volume, time, side, orderType, misc], ...], "trade", pair]
+func parseKrakenTrades(data []byte) ([]TradeEvent, error) {
+	var msg []json.RawMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, err
+	}
+	if len(msg) < 4 {
+		return nil, fmt.Errorf("unexpected message length %d", len(msg))
+	}
+
+	var channel, pair string
+	if err := json.Unmarshal(msg[len(msg)-2], &channel); err != nil {
+		return nil, err
+	}
+	if channel != "trade" {
+		return nil, nil
+	}
+	if err := json.Unmarshal(msg[len(msg)-1], &pair); err != nil {
+		return nil, err
+	}
+
+	var rows [][]interface{}
+	if err := json.Unmarshal(msg[1], &rows); err != nil {
+		return nil, err
+	}
+
+	events := make([]TradeEvent, 0, len(rows))
+	for _, row := range rows {
+		if len(row) < 3 {
+			continue
+		}
+		price, _ := strconv.ParseFloat(fmt.Sprint(row[0]), 64)
+		qty, _ := strconv.ParseFloat(fmt.Sprint(row[1]), 64)
+		ts, _ := strconv.ParseFloat(fmt.Sprint(row[2]), 64)
+		events = append(events, TradeEvent{
+			Symbol:    pair,
+			Price:     price,
+			Quantity:  qty,
+			Timestamp: time.Unix(0, int64(ts*float64(time.Second))),
+		})
+	}
+	return events, nil
+}
+
+// krakenBookDepths are the depths the book channel accepts
+var krakenBookDepths = []int{10, 25, 100, 500, 1000}
+
+// StreamOrderBook maintains a local order book from the book channel,
+// verifying Kraken's CRC32 checksum after every update and resubscribing
+// for a fresh snapshot on mismatch
+func (k *KrakenClient) StreamOrderBook(ctx context.Context, symbol string, depth int) (*OrderBook, error) {
+	if !k.connected {
+		return nil, ErrNotConnected
+	}
+
+	subDepth := krakenBookDepths[len(krakenBookDepths)-1]
+	for _, d := range krakenBookDepths {
+		if d >= depth {
+			subDepth = d
+			break
+		}
+	}
+
+	native := nativeSymbol(k, symbol)
+	book := NewOrderBook(symbol, depth)
+
+	conn, err := k.subscribeBook(ctx, native, subDepth)
+	if err != nil {
+		return nil, err
+	}
+	k.events.publish(ConnectionEvent{Stream: "book", Symbol: symbol, State: Connected})
+
+	go func() {
+		defer k.events.publish(ConnectionEvent{Stream: "book", Symbol: symbol, State: Disconnected})
+		for {
+			err := k.syncOrderBook(ctx, conn, book, subDepth)
+			conn.Close()
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Kraken order book for %s lost sync: %v", symbol, err)
+			book.Invalidate()
+
+			for attempt := 0; ; attempt++ {
+				k.events.publish(ConnectionEvent{Stream: "book", Symbol: symbol, State: Reconnecting, Attempt: attempt + 1, Err: err})
+				if !backoff(ctx, attempt) {
+					return
+				}
+				if conn, err = k.subscribeBook(ctx, native, subDepth); err == nil {
+					break
+				}
+				log.Printf("Kraken book resubscribe for %s failed: %v", symbol, err)
+			}
+			k.events.publish(ConnectionEvent{Stream: "book", Symbol: symbol, State: Connected})
+		}
+	}()
+
+	return book, nil
+}
+
+func (k *KrakenClient) subscribeBook(ctx context.Context, pair string, depth int) (*websocket.Conn, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, k.wsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	subscribe := map[string]interface{}{
+		"event": "subscribe",
+		"pair":  []string{pair},
+		"subscription": map[string]interface{}{
+			"name":  "book",
+			"depth": depth,
+		},
+	}
+	if err := conn.WriteJSON(subscribe); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// syncOrderBook applies the snapshot and updates from a book subscription
+// until the socket fails or a checksum mismatch is detected
+func (k *KrakenClient) syncOrderBook(ctx context.Context, conn *websocket.Conn, book *OrderBook, depth int) error {
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	state := krakenBook{
+		asks: make(map[float64][2]string),
+		bids: make(map[float64][2]string),
+	}
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		if len(data) == 0 || data[0] != '[' {
+			continue
+		}
+
+		var msg []json.RawMessage
+		if err := json.Unmarshal(data, &msg); err != nil || len(msg) < 4 {
+			continue
+		}
+
+		var checksum string
+		for _, raw := range msg[1 : len(msg)-2] {
+			var payload map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &payload); err != nil {
+				return fmt.Errorf("decoding book message: %w", err)
+			}
+			for key, value := range payload {
+				if key == "c" {
+					json.Unmarshal(value, &checksum)
+					continue
+				}
+				var rows [][]string
+				if err := json.Unmarshal(value, &rows); err != nil {
+					return fmt.Errorf("decoding book levels: %w", err)
+				}
+				switch key {
+				case "as":
+					state.asks = make(map[float64][2]string)
+					state.apply(state.asks, rows)
+				case "bs":
+					state.bids = make(map[float64][2]string)
+					state.apply(state.bids, rows)
+				case "a":
+					state.apply(state.asks, rows)
+				case "b":
+					state.apply(state.bids, rows)
+				}
+			}
+		}
+		state.truncate(depth)
+
+		if checksum != "" {
+			if got := state.checksum(); got != checksum {
+				return fmt.Errorf("checksum mismatch: expected %s, computed %s", checksum, got)
+			}
+		}
+
+		// The book is small and bounded by depth, so republish it whole
+		book.Reset(state.levels(state.bids, true), state.levels(state.asks, false))
+	}
+}
+
+// krakenBook keeps levels with the exact strings Kraken sent, which the
+// checksum is computed over
+type krakenBook struct {
+	asks map[float64][2]string
+	bids map[float64][2]string
+}
+
+func (b *krakenBook) apply(side map[float64][2]string, rows [][]string) {
+	for _, row := range rows {
+		if len(row) < 2 {
+			continue
+		}
+		price, err := strconv.ParseFloat(row[0], 64)
+		if err != nil {
+			continue
+		}
+		volume, _ := strconv.ParseFloat(row[1], 64)
+		if volume == 0 {
+			delete(side, price)
+		} else {
+			side[price] = [2]string{row[0], row[1]}
+		}
+	}
+}
+
+func (b *krakenBook) truncate(depth int) {
+	for _, p := range b.sortedPrices(b.asks, false)[min(depth, len(b.asks)):] {
+		delete(b.asks, p)
+	}
+	for _, p := range b.sortedPrices(b.bids, true)[min(depth, len(b.bids)):] {
+		delete(b.bids, p)
+	}
+}
+
+func (b *krakenBook) sortedPrices(side map[float64][2]string, descending bool) []float64 {
+	prices := make([]float64, 0, len(side))
+	for p := range side {
+		prices = append(prices, p)
+	}
+	sort.Slice(prices, func(i, j int) bool {
+		if descending {
+			return prices[i] > prices[j]
+		}
+		return prices[i] < prices[j]
+	})
+	return prices
+}
+
+func (b *krakenBook) levels(side map[float64][2]string, descending bool) []PriceLevel {
+	prices := b.sortedPrices(side, descending)
+	levels := make([]PriceLevel, 0, len(prices))
+	for _, p := range prices {
+		qty, _ := strconv.ParseFloat(side[p][1], 64)
+		levels = append(levels, PriceLevel{Price: p, Quantity: qty})
+	}
+	return levels
+}
+
+// checksum computes the CRC32 of the top ten asks then bids, each level
+// written as price and volume with the decimal point and leading zeros removed
+func (b *krakenBook) checksum() string {
+	var sb strings.Builder
+	write := func(side map[float64][2]string, descending bool) {
+		prices := b.sortedPrices(side, descending)
+		for _, p := range prices[:min(10, len(prices))] {
+			for _, field := range side[p] {
+				sb.WriteString(strings.TrimLeft(strings.ReplaceAll(field, ".", ""), "0"))
+			}
+		}
+	}
+	write(b.asks, false)
+	write(b.bids, true)
+	return strconv.FormatUint(uint64(crc32.ChecksumIEEE([]byte(sb.String()))), 10)
+}
+
+// StreamExecutions subscribes to the private ownTrades and openOrders
+// channels, fetching a fresh token and resubscribing when the socket drops
+func (k *KrakenClient) StreamExecutions(ctx context.Context) (<-chan ExecutionReport, error) {
+	if !k.connected {
+		return nil, ErrNotConnected
+	}
+
+	conn, err := k.subscribePrivate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan ExecutionReport, 1000)
+	go func() {
+		defer close(out)
+		orders := make(map[string]*krakenOrderState)
+		for attempt := 0; ; {
+			err := k.readExecutions(ctx, conn, orders, out)
+			conn.Close()
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Kraken private stream error: %v", err)
+
+			for {
+				if !backoff(ctx, attempt) {
+					return
+				}
+				attempt++
+				if conn, err = k.subscribePrivate(ctx); err == nil {
+					attempt = 0
+					break
+				}
+				log.Printf("Kraken private stream reconnect failed: %v", err)
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+func (k *KrakenClient) subscribePrivate(ctx context.Context) (*websocket.Conn, error) {
+	var res struct {
+		Token string `json:"token"`
+	}
+	if err := k.privateRequest(ctx, "/0/private/GetWebSocketsToken", nil, &res); err != nil {
+		return nil, err
+	}
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, k.wsAuthURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sub := range []map[string]interface{}{
+		{"name": "ownTrades", "token": res.Token, "snapshot": false},
+		{"name": "openOrders", "token": res.Token},
+	} {
+		if err := conn.WriteJSON(map[string]interface{}{"event": "subscribe", "subscription": sub}); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// krakenOrderState remembers order details that openOrders only sends once
+type krakenOrderState struct {
+	pair     string
+	side     order.Side
+	status   string
+	volExec  float64
+	avgPrice float64
+}
+
+func (k *KrakenClient) readExecutions(ctx context.Context, conn *websocket.Conn, orders map[string]*krakenOrderState, out chan<- ExecutionReport) error {
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		if len(data) == 0 || data[0] != '[' {
+			continue
+		}
+
+		var msg []json.RawMessage
+		if err := json.Unmarshal(data, &msg); err != nil || len(msg) < 2 {
+			continue
+		}
+		var channel string
+		json.Unmarshal(msg[1], &channel)
+
+		var reports []ExecutionReport
+		switch channel {
+		case "openOrders":
+			reports = k.parseOpenOrders(msg[0], orders)
+		case "ownTrades":
+			reports = k.parseOwnTrades(msg[0], orders)
+		}
+
+		for _, r := range reports {
+			select {
+			case out <- r:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+func (k *KrakenClient) parseOpenOrders(raw json.RawMessage, orders map[string]*krakenOrderState) []ExecutionReport {
+	var entries []map[string]struct {
+		Status   string `json:"status"`
+		VolExec  string `json:"vol_exec"`
+		AvgPrice string `json:"avg_price"`
+		Descr    struct {
+			Pair string `json:"pair"`
+			Type string `json:"type"`
+		} `json:"descr"`
+	}
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		log.Printf("Kraken openOrders decode error: %v", err)
+		return nil
+	}
+
+	var reports []ExecutionReport
+	for _, entry := range entries {
+		for txid, update := range entry {
+			state, ok := orders[txid]
+			if !ok {
+				state = &krakenOrderState{}
+				orders[txid] = state
+			}
+			if update.Descr.Pair != "" {
+				state.pair = update.Descr.Pair
+				state.side = krakenSide(update.Descr.Type)
+			}
+			if update.Status != "" {
+				state.status = update.Status
+			}
+			if update.VolExec != "" {
+				state.volExec, _ = strconv.ParseFloat(update.VolExec, 64)
+			}
+			if update.AvgPrice != "" {
+				state.avgPrice, _ = strconv.ParseFloat(update.AvgPrice, 64)
+			}
+
+			reports = append(reports, state.report(k, txid))
+			if state.status == "closed" || state.status == "canceled" || state.status == "expired" {
+				delete(orders, txid)
+			}
+		}
+	}
+	return reports
+}
+
+func (k *KrakenClient) parseOwnTrades(raw json.RawMessage, orders map[string]*krakenOrderState) []ExecutionReport {
+	var entries []map[string]struct {
+		OrderTxID string `json:"ordertxid"`
+		Pair      string `json:"pair"`
+		Time      string `json:"time"`
+		Type      string `json:"type"`
+		Price     string `json:"price"`
+		Vol       string `json:"vol"`
+		Fee       string `json:"fee"`
+	}
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		log.Printf("Kraken ownTrades decode error: %v", err)
+		return nil
+	}
+
+	var reports []ExecutionReport
+	for _, entry := range entries {
+		for tradeID, t := range entry {
+			state, ok := orders[t.OrderTxID]
+			if !ok {
+				state = &krakenOrderState{pair: t.Pair, side: krakenSide(t.Type), status: "open"}
+			}
+
+			report := state.report(k, t.OrderTxID)
+			report.TradeID = tradeID
+			report.LastFillPrice, _ = strconv.ParseFloat(t.Price, 64)
+			report.LastFillQuantity, _ = strconv.ParseFloat(t.Vol, 64)
+			report.Fee, _ = strconv.ParseFloat(t.Fee, 64)
+			_, report.FeeCurrency = splitSymbol(t.Pair)
+			if ts, err := strconv.ParseFloat(t.Time, 64); err == nil {
+				report.Timestamp = time.Unix(0, int64(ts*float64(time.Second)))
+			}
+			if report.Status == order.SentToExchange {
+				report.Status = order.PartiallyFilled
+			}
+			reports = append(reports, report)
+		}
+	}
+	return reports
+}
+
+func (s *krakenOrderState) report(k *KrakenClient, txid string) ExecutionReport {
+	symbol := s.pair
+	if sym, err := k.FromExchange(s.pair); err == nil {
+		symbol = sym.String()
+	}
+	info := krakenOrderInfo{Status: s.status, VolExec: strconv.FormatFloat(s.volExec, 'f', -1, 64)}
+	return ExecutionReport{
+		OrderID:        txid,
+		Symbol:         symbol,
+		Side:           s.side,
+		Status:         info.status(),
+		FilledQuantity: s.volExec,
+		AveragePrice:   s.avgPrice,
+		Timestamp:      time.Now(),
+	}
+}
+
+func krakenSide(side string) order.Side {
+	if side == "sell" {
+		return order.Sell
+	}
+	return order.Buy
+}
+
+// krakenOrderInfo is the subset of QueryOrders fields we rely on
+type krakenOrderInfo struct {
+	Status  string `json:"status"`
+	Volume  string `json:"vol"`
+	VolExec string `json:"vol_exec"`
+}
+
+func (i krakenOrderInfo) status() order.Status {
+	executed, _ := strconv.ParseFloat(i.VolExec, 64)
+	switch i.Status {
+	case "pending":
+		return order.SentToExchange
+	case "open":
+		if executed > 0 {
+			return order.PartiallyFilled
+		}
+		return order.SentToExchange
+	case "closed":
+		return order.Filled
+	case "canceled", "expired":
+		return order.Cancelled
+	default:
+		return order.Failed
+	}
+}
+
+// krakenResponse is the envelope shared by all REST endpoints
+type krakenResponse struct {
+	Error  []string        `json:"error"`
+	Result json.RawMessage `json:"result"`
+}
+
+func (k *KrakenClient) publicRequest(ctx context.Context, path string, params url.Values, out interface{}) error {
+	endpoint := k.restURL + path
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	return k.do(req, out)
+}
+
+func (k *KrakenClient) privateRequest(ctx context.Context, path string, params url.Values, out interface{}) error {
+	if params == nil {
+		params = url.Values{}
+	}
+	nonce := k.nextNonce()
+	params.Set("nonce", strconv.FormatInt(nonce, 10))
+	body := params.Encode()
+
+	signature, err := k.sign(path, nonce, body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.restURL+path, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("API-Key", k.apiKey)
+	req.Header.Set("API-Sign", signature)
+	return k.do(req, out)
+}
+
+func (k *KrakenClient) do(req *http.Request, out interface{}) error {
+	resp, err := k.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var res krakenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		if resp.StatusCode == http.StatusBadGateway || resp.StatusCode == http.StatusGatewayTimeout {
+			// The gateway may have forwarded the request before failing
+			return fmt.Errorf("%w: kraken: HTTP %d", ErrUnknownOutcome, resp.StatusCode)
+		}
+		return fmt.Errorf("kraken: error decoding response (HTTP %d): %w", resp.StatusCode, err)
+	}
+	if len(res.Error) > 0 {
+		// Kraken reports exhausted counters in the body of a 200 response
+		for _, e := range res.Error {
+			if strings.Contains(e, "Rate limit exceeded") || strings.Contains(e, "Too many requests") {
+				k.limiter.Backoff(15 * time.Second)
+				return &RateLimitError{Exchange: "kraken", Class: krakenRequestCosts(req)[0].Class, RetryAfter: 15 * time.Second}
+			}
+		}
+		return krakenError(res.Error)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(res.Result, out)
+}
+
+// krakenErrorKinds maps Kraken error messages onto the common errors
+var krakenErrorKinds = map[string]error{
+	"EOrder:Insufficient funds":           ErrInsufficientFunds,
+	"EOrder:Insufficient margin":          ErrInsufficientFunds,
+	"EFunding:Insufficient funds":         ErrInsufficientFunds,
+	"EOrder:Unknown order":                ErrOrderNotFound,
+	"EOrder:Invalid order":                ErrInvalidOrder,
+	"EOrder:Invalid price":                ErrInvalidOrder,
+	"EOrder:Order minimum not met":        ErrInvalidOrder,
+	"EOrder:Cost minimum not met":         ErrInvalidOrder,
+	"EOrder:Tick size check failed":       ErrInvalidOrder,
+	"EOrder:Orders limit exceeded":        ErrInvalidOrder,
+	"EOrder:Post only order":              ErrInvalidOrder,
+	"EGeneral:Invalid arguments":          ErrInvalidOrder,
+	"EQuery:Unknown asset pair":           ErrInvalidOrder,
+	"EService:Unavailable":                ErrMaintenance,
+	"EService:Market in cancel_only mode": ErrMaintenance,
+	"EService:Market in post_only mode":   ErrMaintenance,
+	"EService:Market in limit_only mode":  ErrMaintenance,
+	"EService:Deadline elapsed":           ErrUnknownOutcome,
+	"EGeneral:Internal error":             ErrUnknownOutcome,
+}
+
+// krakenError converts the errors of a Kraken response into a VenueError,
+// classified by the first one that is known. Messages can carry details
+// after the error name, e.g. "EGeneral:Invalid arguments:volume".
+func krakenError(errs []string) error {
+	e := &VenueError{Exchange: "kraken", Code: errs[0], Message: strings.Join(errs, "; ")}
+	for _, msg := range errs {
+		for name, kind := range krakenErrorKinds {
+			if msg == name || strings.HasPrefix(msg, name+":") {
+				e.Code, e.Err = msg, kind
+				return e
+			}
+		}
+	}
+	return e
+}
+
+// sign computes API-Sign as HMAC-SHA512(path + SHA256(nonce + body)) keyed
+// with the base64 decoded secret
+func (k *KrakenClient) sign(path string, nonce int64, body string) (string, error) {
+	secret, err := base64.StdEncoding.DecodeString(k.apiSecret)
+	if err != nil {
+		return "", fmt.Errorf("kraken: invalid api secret: %w", err)
+	}
+
+	sha := sha256.New()
+	sha.Write([]byte(strconv.FormatInt(nonce, 10) + body))
+
+	mac := hmac.New(sha512.New, secret)
+	mac.Write(append([]byte(path), sha.Sum(nil)...))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// nextNonce returns a strictly increasing nonce, even for calls within the
+// same millisecond
+func (k *KrakenClient) nextNonce() int64 {
+	k.nonceMutex.Lock()
+	defer k.nonceMutex.Unlock()
+
+	nonce := time.Now().UnixMilli()
+	if nonce <= k.lastNonce {
+		nonce = k.lastNonce + 1
+	}
+	k.lastNonce = nonce
+	return nonce
+}
+
+// krakenOHLCIntervals are the supported candle sizes in minutes
+var krakenOHLCIntervals = map[time.Duration]int{
+	time.Minute:         1,
+	5 * time.Minute:     5,
+	15 * time.Minute:    15,
+	30 * time.Minute:    30,
+	time.Hour:           60,
+	4 * time.Hour:       240,
+	24 * time.Hour:      1440,
+	7 * 24 * time.Hour:  10080,
+	15 * 24 * time.Hour: 21600,
+}
+
+// krakenPage splits a public history response into the rows of the
+// requested pair and the cursor for the next page
+func krakenPage(raw map[string]json.RawMessage) ([][]interface{}, json.RawMessage, error) {
+	var rows [][]interface{}
+	for key, value := range raw {
+		if key == "last" {
+			continue
+		}
+		if err := json.Unmarshal(value, &rows); err != nil {
+			return nil, nil, fmt.Errorf("kraken: error decoding history: %w", err)
+		}
+	}
+	return rows, raw["last"], nil
+}
+
+// krakenFloat reads a numeric field that Kraken encodes as a string or number
+func krakenFloat(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case string:
+		f, _ := strconv.ParseFloat(n, 64)
+		return f
+	}
+	return 0
+}
+
+// GetKlines returns the candles after start. Kraken only serves the most
+// recent 720 candles of each interval; older ones are not available.
+func (k *KrakenClient) GetKlines(ctx context.Context, symbol string, interval time.Duration, start, end time.Time) ([]Kline, time.Time, error) {
+	minutes, ok := krakenOHLCIntervals[interval]
+	if !ok {
+		return nil, start, fmt.Errorf("%w: kraken has no %v candles", ErrNotSupported, interval)
+	}
+
+	params := url.Values{
+		"pair":     {krakenRESTPair(nativeSymbol(k, symbol))},
+		"interval": {strconv.Itoa(minutes)},
+		"since":    {strconv.FormatInt(start.Unix()-1, 10)},
+	}
+	var res map[string]json.RawMessage
+	if err := k.publicRequest(ctx, "/0/public/OHLC", params, &res); err != nil {
+		return nil, start, err
+	}
+	rows, _, err := krakenPage(res)
+	if err != nil {
+		return nil, start, err
+	}
+
+	var klines []Kline
+	for _, row := range rows {
+		if len(row) < 8 {
+			continue
+		}
+		openTime := time.Unix(int64(krakenFloat(row[0])), 0)
+		if openTime.Before(start) || !openTime.Before(end) {
+			continue
+		}
+		klines = append(klines, Kline{
+			Symbol:   symbol,
+			Interval: interval,
+			OpenTime: openTime,
+			Open:     krakenFloat(row[1]),
+			High:     krakenFloat(row[2]),
+			Low:      krakenFloat(row[3]),
+			Close:    krakenFloat(row[4]),
+			Volume:   krakenFloat(row[6]),
+			Trades:   int64(krakenFloat(row[7])),
+		})
+	}
+
+	// Everything available up to now was returned in one page
+	return klines, end, nil
+}
+
+// GetHistoricalTrades returns up to 1000 trades starting at start
+func (k *KrakenClient) GetHistoricalTrades(ctx context.Context, symbol string, start, end time.Time) ([]HistoricalTrade, time.Time, error) {
+	params := url.Values{
+		"pair":  {krakenRESTPair(nativeSymbol(k, symbol))},
+		"since": {strconv.FormatInt(start.UnixNano(), 10)},
+		"count": {"1000"},
+	}
+	var res map[string]json.RawMessage
+	if err := k.publicRequest(ctx, "/0/public/Trades", params, &res); err != nil {
+		return nil, start, err
+	}
+	rows, last, err := krakenPage(res)
+	if err != nil {
+		return nil, start, err
+	}
+	if len(rows) == 0 {
+		return nil, end, nil
+	}
+
+	var trades []HistoricalTrade
+	for _, row := range rows {
+		if len(row) < 7 {
+			continue
+		}
+		secs := krakenFloat(row[2])
+		ts := time.Unix(0, int64(secs*float64(time.Second)))
+		if !ts.Before(end) {
+			break
+		}
+		trade := HistoricalTrade{
+			Symbol:    symbol,
+			Price:     krakenFloat(row[0]),
+			Quantity:  krakenFloat(row[1]),
+			Side:      order.Buy,
+			Timestamp: ts,
+		}
+		if side, _ := row[3].(string); side == "s" {
+			trade.Side = order.Sell
+		}
+		trade.ID = strconv.FormatInt(int64(krakenFloat(row[6])), 10)
+		trades = append(trades, trade)
+	}
+
+	// The cursor is the nanosecond timestamp to continue from
+	var cursor string
+	if err := json.Unmarshal(last, &cursor); err != nil {
+		return trades, end, nil
+	}
+	ns, err := strconv.ParseInt(cursor, 10, 64)
+	if err != nil || len(trades) < len(rows) {
+		return trades, end, nil
+	}
+	return trades, time.Unix(0, ns), nil
+}
+
+// ToExchange converts a canonical symbol into a Kraken websocket pair name (XBT/USDT)
+func (k *KrakenClient) ToExchange(s Symbol) string {
+	base, quote := s.Base, s.Quote
+	if alias, ok := krakenSymbolAliases[base]; ok {
+		base = alias
+	}
+	if alias, ok := krakenSymbolAliases[quote]; ok {
+		quote = alias
+	}
+	return base + "/" + quote
+}
+
+// FromExchange converts a Kraken pair name into a canonical symbol
+func (k *KrakenClient) FromExchange(native string) (Symbol, error) {
+	return ParseSymbol(native)
+}
+
+// krakenRESTPair converts a websocket pair name (XBT/USDT) into the REST
+// altname (XBTUSDT)
+func krakenRESTPair(symbol string) string {
+	return strings.ReplaceAll(symbol, "/", "")
+}
@@ -0,0 +1,232 @@
+package exchange
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/trading-system/execution-engine/internal/order"
+)
+
+// newTestKraken returns a connected client talking to a stub REST API that
+// answers each path with the given result or error list
+func newTestKraken(t *testing.T, handler http.HandlerFunc) (*KrakenClient, *httptest.Server) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	k := NewKrakenClient()
+	k.SetCredentials("key", base64.StdEncoding.EncodeToString([]byte("secret")))
+	k.SetEndpoints(srv.URL, "ws"+strings.TrimPrefix(srv.URL, "http"), "")
+	k.connected = true
+	return k, srv
+}
+
+func writeKraken(w http.ResponseWriter, result interface{}, errs ...string) {
+	if errs == nil {
+		errs = []string{}
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"error": errs, "result": result})
+}
+
+func TestKrakenPlaceOrder(t *testing.T) {
+	k, _ := newTestKraken(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/0/private/AddOrder" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("API-Key") != "key" || r.Header.Get("API-Sign") == "" {
+			t.Errorf("request not signed: %v", r.Header)
+		}
+		r.ParseForm()
+		for field, want := range map[string]string{"pair": "XBTUSDT", "type": "buy", "ordertype": "limit", "price": "50000", "volume": "0.1"} {
+			if got := r.PostForm.Get(field); got != want {
+				t.Errorf("%s = %q, want %q", field, got, want)
+			}
+		}
+		if r.PostForm.Get("nonce") == "" {
+			t.Error("missing nonce")
+		}
+		writeKraken(w, map[string]interface{}{"txid": []string{"OABC-123"}})
+	})
+
+	o := &order.Order{Symbol: "BTC/USDT", Type: order.Limit, Side: order.Buy, Price: 50000, Quantity: 0.1}
+	id, err := k.PlaceOrder(context.Background(), o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "OABC-123" {
+		t.Errorf("id = %q, want OABC-123", id)
+	}
+}
+
+func TestKrakenErrors(t *testing.T) {
+	tests := []struct {
+		errs []string
+		want error
+	}{
+		{[]string{"EOrder:Insufficient funds"}, ErrInsufficientFunds},
+		{[]string{"EGeneral:Invalid arguments:volume"}, ErrInvalidOrder},
+		{[]string{"EService:Unavailable"}, ErrMaintenance},
+		{[]string{"EAPI:Rate limit exceeded"}, ErrRateLimited},
+		{[]string{"EOrder:Orders limit exceeded"}, ErrInvalidOrder},
+	}
+	for _, tt := range tests {
+		k, _ := newTestKraken(t, func(w http.ResponseWriter, r *http.Request) {
+			writeKraken(w, nil, tt.errs...)
+		})
+		o := &order.Order{Symbol: "BTC/USDT", Type: order.Market, Side: order.Sell, Quantity: 1}
+		if _, err := k.PlaceOrder(context.Background(), o); !errors.Is(err, tt.want) {
+			t.Errorf("%v: err = %v, want %v", tt.errs, err, tt.want)
+		}
+	}
+}
+
+func TestKrakenGetOrderStatus(t *testing.T) {
+	k, _ := newTestKraken(t, func(w http.ResponseWriter, r *http.Request) {
+		writeKraken(w, map[string]interface{}{
+			"OABC-123": map[string]interface{}{"status": "closed", "vol": "1", "vol_exec": "1"},
+		})
+	})
+
+	status, err := k.GetOrderStatus("OABC-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != order.Filled {
+		t.Errorf("status = %v, want Filled", status)
+	}
+
+	if _, err := k.GetOrderStatus("OUNKNOWN"); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("unknown order err = %v, want ErrOrderNotFound", err)
+	}
+}
+
+func TestKrakenCancelNothingCancelled(t *testing.T) {
+	k, _ := newTestKraken(t, func(w http.ResponseWriter, r *http.Request) {
+		writeKraken(w, map[string]interface{}{"count": 0})
+	})
+	if err := k.CancelOrder("OABC-123"); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("err = %v, want ErrOrderNotFound", err)
+	}
+}
+
+func TestKrakenStreamTradesReconnects(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	connections := make(chan *websocket.Conn, 2)
+	k, _ := newTestKraken(t, func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		var sub struct {
+			Event string   `json:"event"`
+			Pair  []string `json:"pair"`
+		}
+		if err := conn.ReadJSON(&sub); err != nil || sub.Event != "subscribe" || len(sub.Pair) != 1 || sub.Pair[0] != "XBT/USDT" {
+			t.Errorf("unexpected subscription %+v: %v", sub, err)
+		}
+		connections <- conn
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	events := k.ConnectionEvents(ctx)
+
+	trades, err := k.StreamTrades(ctx, "BTC/USDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sendTrade := func(conn *websocket.Conn, price string) {
+		msg := `[0,[["` + price + `","0.5","1700000000.123","b","l",""]],"trade","XBT/USDT"]`
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	nextTrade := func() TradeEvent {
+		select {
+		case trade := <-trades:
+			return trade
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for a trade")
+		}
+		return TradeEvent{}
+	}
+
+	first := <-connections
+	sendTrade(first, "50000.1")
+	if trade := nextTrade(); trade.Symbol != "BTC/USDT" || trade.Price != 50000.1 || trade.Quantity != 0.5 {
+		t.Errorf("unexpected trade %+v", trade)
+	}
+
+	// Dropping the socket must resubscribe and keep the channel open
+	first.Close()
+	second := <-connections
+	sendTrade(second, "50001")
+	if trade := nextTrade(); trade.Price != 50001 {
+		t.Errorf("unexpected trade after reconnect %+v", trade)
+	}
+
+	var states []ConnectionState
+	for len(states) < 3 {
+		select {
+		case e := <-events:
+			states = append(states, e.State)
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for connection events, got %v", states)
+		}
+	}
+	if states[0] != Connected || states[1] != Reconnecting || states[2] != Connected {
+		t.Errorf("states = %v, want Connected, Reconnecting, Connected", states)
+	}
+
+	k.Disconnect()
+	select {
+	case _, ok := <-trades:
+		if ok {
+			t.Error("trade channel still open after Disconnect")
+		}
+	case <-ctx.Done():
+		t.Fatal("trade channel not closed after Disconnect")
+	}
+	second.Close()
+}
+
+func TestKrakenBookChecksum(t *testing.T) {
+	// The example book from Kraken's websocket checksum documentation
+	book := krakenBook{asks: make(map[float64][2]string), bids: make(map[float64][2]string)}
+	var asks, bids [][]string
+	for _, p := range []string{"0.05005", "0.05010", "0.05015", "0.05020", "0.05025", "0.05030", "0.05035", "0.05040", "0.05045", "0.05050"} {
+		asks = append(asks, []string{p, "0.00000500"})
+	}
+	for _, p := range []string{"0.05000", "0.04995", "0.04990", "0.04980", "0.04975", "0.04970", "0.04965", "0.04960", "0.04955", "0.04950"} {
+		bids = append(bids, []string{p, "0.00000500"})
+	}
+	book.apply(book.asks, asks)
+	book.apply(book.bids, bids)
+
+	tests := []struct {
+		name   string
+		update func()
+		want   string
+	}{
+		{"documented", func() {}, "974947235"},
+		// Levels beyond the top ten do not count
+		{"deep levels", func() { book.apply(book.asks, [][]string{{"0.06000", "1.00000000"}}) }, "974947235"},
+		// Removing a level within the top ten changes it
+		{"removed level", func() { book.apply(book.bids, [][]string{{"0.05000", "0.00000000"}}) }, "2391687434"},
+	}
+	for _, tt := range tests {
+		tt.update()
+		if got := book.checksum(); got != tt.want {
+			t.Errorf("%s: checksum = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/trading-system/execution-engine/internal/order"
+)
+
+// OrderModifier is implemented by exchanges that can amend the price and
+// quantity of a working limit order in place. The order's ID is its
+// exchange order ID and quantity is the new total, including any part
+// already filled. ModifyOrder returns the ID the order has afterwards.
+type OrderModifier interface {
+	ModifyOrder(ctx context.Context, o *order.Order, price, quantity float64) (string, error)
+}
+
+// ModifyOrder changes the price and quantity of a working limit order,
+// amending it natively where the exchange supports it and by cancel and
+// replace otherwise. A replacement gets a new exchange order ID for the
+// unfilled remainder, so fills racing the cancel can leave the order
+// overfilled by what they filled. ErrReplaceFailed means the order was
+// cancelled but its replacement could not be placed.
+func (m *Manager) ModifyOrder(ctx context.Context, exchangeName string, o *order.Order, price, quantity float64) (string, error) {
+	if o.Type != order.Limit || price <= 0 || quantity <= o.FilledQuantity {
+		return "", fmt.Errorf("%w: cannot modify to %v @ %v with %v filled", ErrInvalidOrder, quantity, price, o.FilledQuantity)
+	}
+
+	var id string
+	err := m.call(exchangeName, func(ex Interface) error {
+		var err error
+		if modifier, ok := ex.(OrderModifier); ok {
+			id, err = modifier.ModifyOrder(ctx, o, price, quantity)
+			return placementError(err)
+		}
+
+		if err := ex.CancelOrder(o.ID); err != nil {
+			return err
+		}
+		replacement := *o
+		replacement.ID = ""
+		replacement.Price = price
+		replacement.Quantity = quantity - o.FilledQuantity
+		if id, err = ex.PlaceOrder(ctx, &replacement); err != nil {
+			return fmt.Errorf("%w: %w", ErrReplaceFailed, placementError(err))
+		}
+		return nil
+	})
+	return id, err
+}
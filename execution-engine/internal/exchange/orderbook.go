@@ -0,0 +1,185 @@
+package exchange
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// OrderBookStreamer is implemented by exchanges that can maintain a local
+// level-2 order book from a REST snapshot plus websocket diffs
+type OrderBookStreamer interface {
+	// StreamOrderBook returns a book that is kept in sync until the context
+	// is cancelled. Gaps in the diff stream trigger a resync from a fresh
+	// snapshot, during which Synced reports false.
+	StreamOrderBook(ctx context.Context, symbol string, depth int) (*OrderBook, error)
+}
+
+// OrderBook is a concurrency-safe local level-2 order book
+type OrderBook struct {
+	symbol    string
+	depth     int
+	bids      map[float64]float64
+	asks      map[float64]float64
+	synced    bool
+	updatedAt time.Time
+	updates   chan struct{}
+	mu        sync.RWMutex
+}
+
+// NewOrderBook creates an empty book. Depth limits the levels returned by
+// Levels; zero returns the whole book.
+func NewOrderBook(symbol string, depth int) *OrderBook {
+	return &OrderBook{
+		symbol:  symbol,
+		depth:   depth,
+		bids:    make(map[float64]float64),
+		asks:    make(map[float64]float64),
+		updates: make(chan struct{}, 1),
+	}
+}
+
+// Symbol returns the symbol the book was requested for
+func (b *OrderBook) Symbol() string {
+	return b.symbol
+}
+
+// Reset replaces the book with a snapshot and marks it as synced
+func (b *OrderBook) Reset(bids, asks []PriceLevel) {
+	b.mu.Lock()
+	b.bids = make(map[float64]float64, len(bids))
+	b.asks = make(map[float64]float64, len(asks))
+	applyLevels(b.bids, bids)
+	applyLevels(b.asks, asks)
+	b.synced = true
+	b.updatedAt = time.Now()
+	b.mu.Unlock()
+
+	b.notify()
+}
+
+// Update applies a diff. Levels with zero quantity are removed.
+func (b *OrderBook) Update(bids, asks []PriceLevel) {
+	b.mu.Lock()
+	applyLevels(b.bids, bids)
+	applyLevels(b.asks, asks)
+	b.updatedAt = time.Now()
+	b.mu.Unlock()
+
+	b.notify()
+}
+
+// Invalidate marks the book as out of sync until the next Reset
+func (b *OrderBook) Invalidate() {
+	b.mu.Lock()
+	b.synced = false
+	b.mu.Unlock()
+}
+
+// Synced reports whether the book currently reflects the venue
+func (b *OrderBook) Synced() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.synced
+}
+
+// UpdatedAt returns when the book last changed
+func (b *OrderBook) UpdatedAt() time.Time {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.updatedAt
+}
+
+// Updates signals after every change. Signals are coalesced, so consumers
+// should read the current state rather than count notifications.
+func (b *OrderBook) Updates() <-chan struct{} {
+	return b.updates
+}
+
+// BestBid returns the highest bid
+func (b *OrderBook) BestBid() (PriceLevel, bool) {
+	bids, _ := b.Levels(1)
+	if len(bids) == 0 {
+		return PriceLevel{}, false
+	}
+	return bids[0], true
+}
+
+// BestAsk returns the lowest ask
+func (b *OrderBook) BestAsk() (PriceLevel, bool) {
+	_, asks := b.Levels(1)
+	if len(asks) == 0 {
+		return PriceLevel{}, false
+	}
+	return asks[0], true
+}
+
+// MidPrice returns the midpoint between the best bid and ask
+func (b *OrderBook) MidPrice() (float64, bool) {
+	bid, okBid := b.BestBid()
+	ask, okAsk := b.BestAsk()
+	if !okBid || !okAsk {
+		return 0, false
+	}
+	return (bid.Price + ask.Price) / 2, true
+}
+
+// Levels returns up to n levels per side, best first. If n is zero the
+// book's configured depth is used.
+func (b *OrderBook) Levels(n int) (bids, asks []PriceLevel) {
+	if n <= 0 {
+		n = b.depth
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return sortedLevels(b.bids, n, true), sortedLevels(b.asks, n, false)
+}
+
+func (b *OrderBook) notify() {
+	select {
+	case b.updates <- struct{}{}:
+	default:
+	}
+}
+
+func applyLevels(side map[float64]float64, levels []PriceLevel) {
+	for _, lvl := range levels {
+		if lvl.Quantity == 0 {
+			delete(side, lvl.Price)
+		} else {
+			side[lvl.Price] = lvl.Quantity
+		}
+	}
+}
+
+func sortedLevels(side map[float64]float64, n int, descending bool) []PriceLevel {
+	levels := make([]PriceLevel, 0, len(side))
+	for price, qty := range side {
+		levels = append(levels, PriceLevel{Price: price, Quantity: qty})
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		if descending {
+			return levels[i].Price > levels[j].Price
+		}
+		return levels[i].Price < levels[j].Price
+	})
+	if n > 0 && len(levels) > n {
+		levels = levels[:n]
+	}
+	return levels
+}
+
+// StreamOrderBook opens a synchronised order book on the specified exchange
+func (m *Manager) StreamOrderBook(ctx context.Context, exchangeName, symbol string, depth int) (*OrderBook, error) {
+	ex, ok := m.GetExchange(exchangeName)
+	if !ok {
+		return nil, ErrExchangeNotFound
+	}
+	streamer, ok := ex.(OrderBookStreamer)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return streamer.StreamOrderBook(ctx, symbol, depth)
+}
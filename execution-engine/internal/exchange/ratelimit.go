@@ -0,0 +1,368 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// EndpointClass groups requests that draw on the same venue limit
+type EndpointClass string
+
+const (
+	ClassRequests EndpointClass = "requests" // general request weight of authenticated calls
+	ClassOrders   EndpointClass = "orders"   // order placement and cancellation
+	ClassPublic   EndpointClass = "public"   // unauthenticated market data
+)
+
+// defaultMaxWait is how long a request may queue for capacity before
+// failing with a RateLimitError
+const defaultMaxWait = 5 * time.Second
+
+var (
+	rateLimitUtilisation = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "exchange_rate_limit_utilisation",
+			Help: "Fraction of an exchange rate limit currently in use",
+		},
+		[]string{"exchange", "class", "interval"},
+	)
+
+	rateLimitRejections = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "exchange_rate_limit_rejections_total",
+			Help: "Requests failed locally because a rate limit was exhausted",
+		},
+		[]string{"exchange", "class"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(rateLimitUtilisation)
+	prometheus.MustRegister(rateLimitRejections)
+}
+
+// RateLimit allows Limit units of weight per Interval for a class. A class
+// may have several limits, e.g. Binance counts orders per 10s and per minute.
+type RateLimit struct {
+	Class    EndpointClass
+	Limit    float64
+	Interval time.Duration
+}
+
+// Cost is the weight a request draws from a class
+type Cost struct {
+	Class  EndpointClass
+	Weight float64
+}
+
+// RateLimitError is returned when a request cannot be sent within the
+// limiter's maximum wait, or when the venue answered it with HTTP 429 or
+// 418. It matches ErrRateLimited with errors.Is.
+type RateLimitError struct {
+	Exchange   string
+	Class      EndpointClass
+	RetryAfter time.Duration
+	StatusCode int // HTTP status from the venue, zero if the limiter held the request back
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s: %s rate limit exhausted, retry after %v", e.Exchange, e.Class, e.RetryAfter)
+}
+
+// Unwrap allows errors.Is(err, ErrRateLimited)
+func (e *RateLimitError) Unwrap() error {
+	return ErrRateLimited
+}
+
+// RateLimiter throttles requests to a single exchange using one token
+// bucket per limit. Buckets are corrected from server-reported usage and
+// frozen entirely while the venue has asked us to back off.
+type RateLimiter struct {
+	exchange    string
+	buckets     map[EndpointClass][]*bucket
+	maxWait     time.Duration
+	bannedUntil time.Time
+	mu          sync.Mutex
+}
+
+type bucket struct {
+	limit    RateLimit
+	tokens   float64
+	refilled time.Time
+}
+
+// RateLimited is implemented by exchanges that throttle their own requests
+type RateLimited interface {
+	RateLimiter() *RateLimiter
+}
+
+// NewRateLimiter creates a limiter for an exchange with the given limits
+func NewRateLimiter(exchange string, limits ...RateLimit) *RateLimiter {
+	l := &RateLimiter{
+		exchange: exchange,
+		buckets:  make(map[EndpointClass][]*bucket),
+		maxWait:  defaultMaxWait,
+	}
+	now := time.Now()
+	for _, limit := range limits {
+		l.buckets[limit.Class] = append(l.buckets[limit.Class], &bucket{limit: limit, tokens: limit.Limit, refilled: now})
+	}
+	return l
+}
+
+// SetMaxWait sets how long requests may queue for capacity. Zero makes the
+// limiter fail fast.
+func (l *RateLimiter) SetMaxWait(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.maxWait = d
+}
+
+// Wait reserves capacity for a request, blocking until it is available. If
+// that would take longer than the maximum wait it returns a RateLimitError
+// without reserving anything.
+func (l *RateLimiter) Wait(ctx context.Context, costs ...Cost) error {
+	l.mu.Lock()
+	now := time.Now()
+
+	var wait time.Duration
+	var class EndpointClass
+	if now.Before(l.bannedUntil) {
+		wait = l.bannedUntil.Sub(now)
+	}
+	for _, c := range costs {
+		for _, b := range l.buckets[c.Class] {
+			if d := b.delay(now, c.Weight); d > wait {
+				wait, class = d, c.Class
+			}
+		}
+	}
+
+	if wait > l.maxWait {
+		l.mu.Unlock()
+		rateLimitRejections.WithLabelValues(l.exchange, string(class)).Inc()
+		return &RateLimitError{Exchange: l.exchange, Class: class, RetryAfter: wait}
+	}
+
+	// Reserve now so that queued requests are released in order
+	for _, c := range costs {
+		for _, b := range l.buckets[c.Class] {
+			b.tokens -= c.Weight
+		}
+	}
+	l.report()
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.refund(costs)
+		return ctx.Err()
+	}
+}
+
+// Observe corrects a limit from the usage the server reports for the
+// interval, which also accounts for requests made by other processes
+func (l *RateLimiter) Observe(class EndpointClass, interval time.Duration, used float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	for _, b := range l.buckets[class] {
+		if b.limit.Interval != interval {
+			continue
+		}
+		b.refill(now)
+		b.tokens = math.Min(b.tokens, b.limit.Limit-used)
+	}
+	l.report()
+}
+
+// Backoff stops all requests for the given duration, as venues demand after
+// answering 429 or 418
+func (l *RateLimiter) Backoff(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if until := time.Now().Add(d); until.After(l.bannedUntil) {
+		l.bannedUntil = until
+		log.Printf("%s rate limited, backing off for %v", l.exchange, d)
+	}
+}
+
+// Utilisation returns the fraction of each class's tightest limit in use
+func (l *RateLimiter) Utilisation() map[EndpointClass]float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	usage := make(map[EndpointClass]float64, len(l.buckets))
+	for class, buckets := range l.buckets {
+		for _, b := range buckets {
+			b.refill(now)
+			usage[class] = math.Max(usage[class], b.utilisation())
+		}
+	}
+	return usage
+}
+
+func (l *RateLimiter) refund(costs []Cost) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, c := range costs {
+		for _, b := range l.buckets[c.Class] {
+			b.tokens = math.Min(b.tokens+c.Weight, b.limit.Limit)
+		}
+	}
+	l.report()
+}
+
+// report exports utilisation metrics. Must be called with mu held.
+func (l *RateLimiter) report() {
+	for class, buckets := range l.buckets {
+		for _, b := range buckets {
+			rateLimitUtilisation.WithLabelValues(l.exchange, string(class), b.limit.Interval.String()).Set(b.utilisation())
+		}
+	}
+}
+
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.refilled)
+	b.refilled = now
+	b.tokens = math.Min(b.limit.Limit, b.tokens+b.limit.Limit*elapsed.Seconds()/b.limit.Interval.Seconds())
+}
+
+// delay returns how long until the bucket can cover the weight
+func (b *bucket) delay(now time.Time, weight float64) time.Duration {
+	b.refill(now)
+	missing := weight - b.tokens
+	if missing <= 0 {
+		return 0
+	}
+	return time.Duration(missing / b.limit.Limit * float64(b.limit.Interval))
+}
+
+func (b *bucket) utilisation() float64 {
+	return math.Max(0, 1-b.tokens/b.limit.Limit)
+}
+
+// unsentError is a request that failed before it was written, so the venue
+// cannot have acted on it
+type unsentError struct {
+	err error
+}
+
+func (e *unsentError) Error() string { return e.err.Error() }
+func (e *unsentError) Unwrap() error { return e.err }
+
+// rateLimitTransport throttles an exchange's HTTP requests through its
+// limiter and feeds rate limit responses and usage headers back into it
+type rateLimitTransport struct {
+	limiter *RateLimiter
+	costs   func(*http.Request) []Cost
+	observe func(*RateLimiter, http.Header)
+	base    http.RoundTripper
+}
+
+func newRateLimitedClient(limiter *RateLimiter, costs func(*http.Request) []Cost, observe func(*RateLimiter, http.Header)) *http.Client {
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &rateLimitTransport{
+			limiter: limiter,
+			costs:   costs,
+			observe: observe,
+			base:    http.DefaultTransport,
+		},
+	}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	costs := t.costs(req)
+	if err := t.limiter.Wait(req.Context(), costs...); err != nil {
+		return nil, &unsentError{err}
+	}
+
+	var wrote atomic.Bool
+	trace := &httptrace.ClientTrace{
+		WroteRequest: func(httptrace.WroteRequestInfo) { wrote.Store(true) },
+	}
+	resp, err := t.base.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	if err != nil {
+		if !wrote.Load() {
+			return nil, &unsentError{err}
+		}
+		return nil, err
+	}
+	if t.observe != nil {
+		t.observe(t.limiter, resp.Header)
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusTeapot {
+		retryAfter := time.Minute
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			retryAfter = time.Duration(secs) * time.Second
+		}
+		resp.Body.Close()
+		t.limiter.Backoff(retryAfter)
+
+		class := ClassRequests
+		if len(costs) > 0 {
+			class = costs[len(costs)-1].Class
+		}
+		return nil, &RateLimitError{Exchange: t.limiter.exchange, Class: class, RetryAfter: retryAfter, StatusCode: resp.StatusCode}
+	}
+	return resp, nil
+}
+
+// parseLimitInterval parses interval suffixes such as 10S, 1M or 1D used in
+// Binance usage headers
+func parseLimitInterval(s string) (time.Duration, bool) {
+	if len(s) < 2 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil {
+		return 0, false
+	}
+	unit := map[string]time.Duration{
+		"S": time.Second,
+		"M": time.Minute,
+		"H": time.Hour,
+		"D": 24 * time.Hour,
+	}[strings.ToUpper(s[len(s)-1:])]
+	if unit == 0 {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
+
+// RateLimiter returns the limiter of the specified exchange
+func (m *Manager) RateLimiter(exchangeName string) (*RateLimiter, bool) {
+	ex, ok := m.GetExchange(exchangeName)
+	if !ok {
+		return nil, false
+	}
+	limited, ok := ex.(RateLimited)
+	if !ok {
+		return nil, false
+	}
+	return limited.RateLimiter(), true
+}
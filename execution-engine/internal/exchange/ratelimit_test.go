@@ -0,0 +1,108 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterWait(t *testing.T) {
+	l := NewRateLimiter("test", RateLimit{Class: ClassOrders, Limit: 10, Interval: 100 * time.Millisecond})
+	ctx := context.Background()
+
+	start := time.Now()
+	if err := l.Wait(ctx, Cost{ClassOrders, 10}); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Millisecond {
+		t.Errorf("full bucket waited %v", elapsed)
+	}
+
+	// An empty bucket refills a tenth of the interval per unit
+	start = time.Now()
+	if err := l.Wait(ctx, Cost{ClassOrders, 1}); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 8*time.Millisecond {
+		t.Errorf("empty bucket waited only %v", elapsed)
+	}
+
+	// Failing fast reserves nothing
+	l.SetMaxWait(0)
+	before := l.Utilisation()[ClassOrders]
+	err := l.Wait(ctx, Cost{ClassOrders, 5})
+	var rateLimited *RateLimitError
+	if !errors.As(err, &rateLimited) || !errors.Is(err, ErrRateLimited) || rateLimited.Class != ClassOrders || rateLimited.StatusCode != 0 {
+		t.Fatalf("err = %v, want a local orders RateLimitError", err)
+	}
+	if after := l.Utilisation()[ClassOrders]; after > before {
+		t.Errorf("utilisation rose from %v to %v after a rejected wait", before, after)
+	}
+
+	// A cancelled wait gives its reservation back
+	l.SetMaxWait(time.Second)
+	time.Sleep(100 * time.Millisecond)
+	l.Wait(ctx, Cost{ClassOrders, 10})
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := l.Wait(cancelled, Cost{ClassOrders, 5}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if u := l.Utilisation()[ClassOrders]; u > 1 {
+		t.Errorf("utilisation = %v after a cancelled wait, want at most 1", u)
+	}
+}
+
+func TestRateLimiterObserveUsage(t *testing.T) {
+	l := NewRateLimiter("binance", binanceRateLimits...)
+	l.SetMaxWait(0)
+
+	header := http.Header{}
+	header.Set("X-MBX-USED-WEIGHT-1M", "1800")
+	header.Set("X-MBX-ORDER-COUNT-10S", "300")
+	observeBinanceUsage(l, header)
+
+	usage := l.Utilisation()
+	if math.Abs(usage[ClassRequests]-0.75) > 0.01 {
+		t.Errorf("requests utilisation = %v, want 0.75", usage[ClassRequests])
+	}
+	if usage[ClassOrders] < 0.99 {
+		t.Errorf("orders utilisation = %v, want 1", usage[ClassOrders])
+	}
+
+	// Orders are exhausted by the 10s count, other weight is still free
+	if err := l.Wait(context.Background(), Cost{ClassOrders, 1}); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("order err = %v, want ErrRateLimited", err)
+	}
+	if err := l.Wait(context.Background(), Cost{ClassRequests, 100}); err != nil {
+		t.Errorf("request err = %v", err)
+	}
+}
+
+func TestRateLimitTransportBackoff(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	l := NewRateLimiter("test", RateLimit{Class: ClassRequests, Limit: 100, Interval: time.Second})
+	client := newRateLimitedClient(l, func(*http.Request) []Cost { return []Cost{{ClassRequests, 1}} }, nil)
+
+	_, err := client.Get(srv.URL)
+	var rateLimited *RateLimitError
+	if !errors.As(err, &rateLimited) || rateLimited.StatusCode != http.StatusTooManyRequests || rateLimited.RetryAfter != time.Second {
+		t.Fatalf("err = %v, want a 429 RateLimitError retrying after 1s", err)
+	}
+
+	// Everything is held back until the venue's backoff has passed
+	l.SetMaxWait(0)
+	err = l.Wait(context.Background(), Cost{ClassRequests, 1})
+	if !errors.As(err, &rateLimited) || rateLimited.RetryAfter <= 0 || rateLimited.RetryAfter > time.Second {
+		t.Errorf("err = %v, want a wait of up to 1s", err)
+	}
+}
@@ -0,0 +1,413 @@
+package exchange
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/trading-system/execution-engine/internal/order"
+)
+
+// Recording entries other than the Interface method names
+const (
+	recordTrade     = "trade"
+	recordExecution = "execution"
+	recordStreamEnd = "end"
+)
+
+// recordedErrors are restored on replay so callers see the same errors.Is
+// matches as in production
+var recordedErrors = []error{
+	ErrExchangeNotFound, ErrNotConnected, ErrOrderNotFound, ErrInsufficientFunds,
+	ErrInvalidOrder, ErrNotSupported, ErrRateLimited, ErrCircuitOpen,
+	ErrUnknownOutcome, ErrMaintenance,
+	context.Canceled, context.DeadlineExceeded,
+}
+
+// recordEntry is one line of a recording
+type recordEntry struct {
+	Time      time.Time        `json:"t"`
+	Method    string           `json:"m"`
+	Key       string           `json:"k,omitempty"` // order ID, symbol or currency
+	Order     *order.Order     `json:"o,omitempty"`
+	Result    json.RawMessage  `json:"r,omitempty"`
+	Err       *recordedError   `json:"e,omitempty"`
+	Stream    int              `json:"s,omitempty"` // stream the event belongs to
+	Trade     *TradeEvent      `json:"tr,omitempty"`
+	Execution *ExecutionReport `json:"x,omitempty"`
+}
+
+type recordedError struct {
+	Message   string          `json:"msg"`
+	Is        string          `json:"is,omitempty"` // message of the matching common error
+	RateLimit *RateLimitError `json:"rl,omitempty"`
+}
+
+func newRecordedError(err error) *recordedError {
+	if err == nil {
+		return nil
+	}
+	rec := &recordedError{Message: err.Error()}
+	for _, target := range recordedErrors {
+		if errors.Is(err, target) {
+			rec.Is = target.Error()
+			break
+		}
+	}
+	errors.As(err, &rec.RateLimit)
+	return rec
+}
+
+// replayedError carries a recorded message and the common error it matched
+type replayedError struct {
+	msg string
+	is  error
+}
+
+func (e *replayedError) Error() string { return e.msg }
+func (e *replayedError) Unwrap() error { return e.is }
+
+func (e *recordedError) err() error {
+	if e == nil {
+		return nil
+	}
+	if e.RateLimit != nil {
+		return e.RateLimit
+	}
+	for _, target := range recordedErrors {
+		if target.Error() == e.Is {
+			return &replayedError{msg: e.Message, is: target}
+		}
+	}
+	return errors.New(e.Message)
+}
+
+// Recorder wraps an exchange and writes every call, its result and every
+// streamed event to a gzip compressed JSON lines recording that Replay can
+// serve back
+type Recorder struct {
+	ex      Interface
+	gz      *gzip.Writer
+	enc     *json.Encoder
+	streams int
+	err     error
+	mu      sync.Mutex
+}
+
+// NewRecorder creates a recorder writing to w. Close must be called to
+// flush the recording.
+func NewRecorder(ex Interface, w io.Writer) *Recorder {
+	gz := gzip.NewWriter(w)
+	return &Recorder{ex: ex, gz: gz, enc: json.NewEncoder(gz)}
+}
+
+// Close flushes the recording and returns the first write error, if any.
+// It does not close the underlying writer.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.gz.Close(); err != nil && r.err == nil {
+		r.err = err
+	}
+	return r.err
+}
+
+func (r *Recorder) record(e recordEntry) {
+	e.Time = time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.enc.Encode(e); err != nil && r.err == nil {
+		r.err = fmt.Errorf("error writing recording: %w", err)
+	}
+}
+
+func (r *Recorder) nextStream() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.streams++
+	return r.streams
+}
+
+func mustMarshal(v interface{}) json.RawMessage {
+	data, _ := json.Marshal(v)
+	return data
+}
+
+// Connect connects the wrapped exchange
+func (r *Recorder) Connect() error {
+	err := r.ex.Connect()
+	r.record(recordEntry{Method: "Connect", Err: newRecordedError(err)})
+	return err
+}
+
+// Disconnect disconnects the wrapped exchange
+func (r *Recorder) Disconnect() error {
+	err := r.ex.Disconnect()
+	r.record(recordEntry{Method: "Disconnect", Err: newRecordedError(err)})
+	return err
+}
+
+// PlaceOrder places an order on the wrapped exchange
+func (r *Recorder) PlaceOrder(ctx context.Context, o *order.Order) (string, error) {
+	sent := *o
+	id, err := r.ex.PlaceOrder(ctx, o)
+	r.record(recordEntry{Method: "PlaceOrder", Key: o.Symbol, Order: &sent, Result: mustMarshal(id), Err: newRecordedError(err)})
+	return id, err
+}
+
+// CancelOrder cancels an order on the wrapped exchange
+func (r *Recorder) CancelOrder(orderID string) error {
+	err := r.ex.CancelOrder(orderID)
+	r.record(recordEntry{Method: "CancelOrder", Key: orderID, Err: newRecordedError(err)})
+	return err
+}
+
+// GetOrderStatus returns an order's status from the wrapped exchange
+func (r *Recorder) GetOrderStatus(orderID string) (order.Status, error) {
+	status, err := r.ex.GetOrderStatus(orderID)
+	r.record(recordEntry{Method: "GetOrderStatus", Key: orderID, Result: mustMarshal(status), Err: newRecordedError(err)})
+	return status, err
+}
+
+// GetBalance returns a balance from the wrapped exchange
+func (r *Recorder) GetBalance(currency string) (float64, error) {
+	balance, err := r.ex.GetBalance(currency)
+	r.record(recordEntry{Method: "GetBalance", Key: currency, Result: mustMarshal(balance), Err: newRecordedError(err)})
+	return balance, err
+}
+
+// StreamTrades opens a trade stream on the wrapped exchange and records
+// every trade it delivers
+func (r *Recorder) StreamTrades(ctx context.Context, symbol string) (chan TradeEvent, error) {
+	stream := r.nextStream()
+	in, err := r.ex.StreamTrades(ctx, symbol)
+	r.record(recordEntry{Method: "StreamTrades", Key: symbol, Stream: stream, Err: newRecordedError(err)})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan TradeEvent, 1000)
+	go func() {
+		defer close(out)
+		for ev := range in {
+			ev := ev
+			r.record(recordEntry{Method: recordTrade, Stream: stream, Trade: &ev})
+			out <- ev
+		}
+		r.record(recordEntry{Method: recordStreamEnd, Stream: stream})
+	}()
+	return out, nil
+}
+
+// StreamExecutions opens the wrapped exchange's private stream, if it has
+// one, and records every report it delivers
+func (r *Recorder) StreamExecutions(ctx context.Context) (<-chan ExecutionReport, error) {
+	streamer, ok := r.ex.(ExecutionStreamer)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+
+	stream := r.nextStream()
+	in, err := streamer.StreamExecutions(ctx)
+	r.record(recordEntry{Method: "StreamExecutions", Stream: stream, Err: newRecordedError(err)})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan ExecutionReport, 1000)
+	go func() {
+		defer close(out)
+		for report := range in {
+			report := report
+			r.record(recordEntry{Method: recordExecution, Stream: stream, Execution: &report})
+			out <- report
+		}
+		r.record(recordEntry{Method: recordStreamEnd, Stream: stream})
+	}()
+	return out, nil
+}
+
+// Replay serves a recording back as an exchange. Each call returns the next
+// recorded result for the same method and order ID, symbol or currency, and
+// streams deliver their recorded events in order without delay. Calls that
+// were not recorded fail with ErrNotRecorded.
+type Replay struct {
+	calls   map[string][]recordEntry // method/key -> entries in recorded order
+	streams map[int][]recordEntry    // stream -> events in recorded order
+	mu      sync.Mutex
+}
+
+// NewReplay loads a recording written by a Recorder
+func NewReplay(r io.Reader) (*Replay, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading recording: %w", err)
+	}
+	defer gz.Close()
+
+	p := &Replay{
+		calls:   make(map[string][]recordEntry),
+		streams: make(map[int][]recordEntry),
+	}
+	dec := json.NewDecoder(gz)
+	for {
+		var e recordEntry
+		if err := dec.Decode(&e); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("error reading recording: %w", err)
+		}
+
+		switch e.Method {
+		case recordTrade, recordExecution, recordStreamEnd:
+			p.streams[e.Stream] = append(p.streams[e.Stream], e)
+		default:
+			key := e.Method + "/" + e.Key
+			p.calls[key] = append(p.calls[key], e)
+		}
+	}
+	return p, nil
+}
+
+func (p *Replay) next(method, key string) (recordEntry, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	k := method + "/" + key
+	entries := p.calls[k]
+	if len(entries) == 0 {
+		return recordEntry{}, fmt.Errorf("%w: %s %s", ErrNotRecorded, method, key)
+	}
+	p.calls[k] = entries[1:]
+	return entries[0], nil
+}
+
+// Connect returns the recorded result of Connect
+func (p *Replay) Connect() error {
+	e, err := p.next("Connect", "")
+	if err != nil {
+		return err
+	}
+	return e.Err.err()
+}
+
+// Disconnect returns the recorded result of Disconnect
+func (p *Replay) Disconnect() error {
+	e, err := p.next("Disconnect", "")
+	if err != nil {
+		return err
+	}
+	return e.Err.err()
+}
+
+// PlaceOrder returns the next recorded placement for the order's symbol
+func (p *Replay) PlaceOrder(ctx context.Context, o *order.Order) (string, error) {
+	e, err := p.next("PlaceOrder", o.Symbol)
+	if err != nil {
+		return "", err
+	}
+	var id string
+	json.Unmarshal(e.Result, &id)
+	return id, e.Err.err()
+}
+
+// CancelOrder returns the next recorded cancellation of the order
+func (p *Replay) CancelOrder(orderID string) error {
+	e, err := p.next("CancelOrder", orderID)
+	if err != nil {
+		return err
+	}
+	return e.Err.err()
+}
+
+// GetOrderStatus returns the next recorded status of the order
+func (p *Replay) GetOrderStatus(orderID string) (order.Status, error) {
+	e, err := p.next("GetOrderStatus", orderID)
+	if err != nil {
+		return order.Pending, err
+	}
+	var status order.Status
+	json.Unmarshal(e.Result, &status)
+	return status, e.Err.err()
+}
+
+// GetBalance returns the next recorded balance of the currency
+func (p *Replay) GetBalance(currency string) (float64, error) {
+	e, err := p.next("GetBalance", currency)
+	if err != nil {
+		return 0, err
+	}
+	var balance float64
+	json.Unmarshal(e.Result, &balance)
+	return balance, e.Err.err()
+}
+
+// StreamTrades replays the next recorded trade stream of the symbol
+func (p *Replay) StreamTrades(ctx context.Context, symbol string) (chan TradeEvent, error) {
+	e, err := p.next("StreamTrades", symbol)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.Err.err(); err != nil {
+		return nil, err
+	}
+
+	ch := make(chan TradeEvent, 1000)
+	go p.serve(ctx, e.Stream, func(ev recordEntry) bool {
+		select {
+		case ch <- *ev.Trade:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}, func() { close(ch) })
+	return ch, nil
+}
+
+// StreamExecutions replays the next recorded execution stream
+func (p *Replay) StreamExecutions(ctx context.Context) (<-chan ExecutionReport, error) {
+	e, err := p.next("StreamExecutions", "")
+	if err != nil {
+		return nil, err
+	}
+	if err := e.Err.err(); err != nil {
+		return nil, err
+	}
+
+	ch := make(chan ExecutionReport, 1000)
+	go p.serve(ctx, e.Stream, func(ev recordEntry) bool {
+		select {
+		case ch <- *ev.Execution:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}, func() { close(ch) })
+	return ch, nil
+}
+
+// serve delivers a stream's events. A stream that was still open when the
+// recording ended stays open until the context is cancelled.
+func (p *Replay) serve(ctx context.Context, stream int, send func(recordEntry) bool, done func()) {
+	defer done()
+
+	p.mu.Lock()
+	events := p.streams[stream]
+	p.mu.Unlock()
+
+	for _, ev := range events {
+		if ev.Method == recordStreamEnd {
+			return
+		}
+		if !send(ev) {
+			return
+		}
+	}
+	<-ctx.Done()
+}
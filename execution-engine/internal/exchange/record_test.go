@@ -0,0 +1,131 @@
+package exchange
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/trading-system/execution-engine/internal/order"
+)
+
+func TestRecordReplayRoundTrip(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	sim := NewSimExchange(SimConfig{TakerFee: 0.001, Balances: map[string]float64{"USDT": 1000}})
+	sim.SetLiquidity("BTC/USDT", []PriceLevel{{Price: 99, Quantity: 5}}, []PriceLevel{{Price: 100, Quantity: 5}})
+
+	var buf bytes.Buffer
+	rec := NewRecorder(sim, &buf)
+	if err := rec.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	streamCtx, stopStreams := context.WithCancel(ctx)
+	trades, err := rec.StreamTrades(streamCtx, "BTC/USDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reports, err := rec.StreamExecutions(streamCtx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buy := &order.Order{Symbol: "BTC/USDT", Type: order.Market, Side: order.Buy, Quantity: 2}
+	filledID, err := rec.PlaceOrder(ctx, buy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	huge := &order.Order{Symbol: "BTC/USDT", Type: order.Limit, Side: order.Buy, Price: 100, Quantity: 100}
+	if _, err := rec.PlaceOrder(ctx, huge); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("err = %v, want ErrInsufficientFunds", err)
+	}
+	status, err := rec.GetOrderStatus(filledID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	balance, err := rec.GetBalance("BTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancelErr := rec.CancelOrder(filledID)
+	if !errors.Is(cancelErr, ErrOrderNotFound) {
+		t.Fatalf("cancel err = %v, want ErrOrderNotFound", cancelErr)
+	}
+
+	sim.ProcessTrade(TradeEvent{Symbol: "BTC/USDT", Price: 100, Quantity: 1})
+	recordedReport := <-reports
+	var recordedTrades []TradeEvent
+	for len(recordedTrades) < 2 {
+		recordedTrades = append(recordedTrades, <-trades)
+	}
+
+	// Wait for both streams to record their end
+	stopStreams()
+	for range trades {
+	}
+	for range reports {
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	replay, err := NewReplay(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := replay.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	if id, err := replay.PlaceOrder(ctx, buy); err != nil || id != filledID {
+		t.Errorf("PlaceOrder = %q, %v, want %q", id, err, filledID)
+	}
+	if _, err := replay.PlaceOrder(ctx, huge); !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("replayed err = %v, want ErrInsufficientFunds", err)
+	}
+	if got, err := replay.GetOrderStatus(filledID); err != nil || got != status {
+		t.Errorf("GetOrderStatus = %v, %v, want %v", got, err, status)
+	}
+	if got, err := replay.GetBalance("BTC"); err != nil || got != balance {
+		t.Errorf("GetBalance = %v, %v, want %v", got, err, balance)
+	}
+	if err := replay.CancelOrder(filledID); !errors.Is(err, ErrOrderNotFound) || err.Error() != cancelErr.Error() {
+		t.Errorf("CancelOrder err = %v, want %v", err, cancelErr)
+	}
+
+	replayedTrades, err := replay.StreamTrades(ctx, "BTC/USDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []TradeEvent
+	for trade := range replayedTrades {
+		got = append(got, trade)
+	}
+	if len(got) != len(recordedTrades) {
+		t.Fatalf("replayed %d trades, want %d", len(got), len(recordedTrades))
+	}
+	for i := range got {
+		if got[i].Price != recordedTrades[i].Price || got[i].Quantity != recordedTrades[i].Quantity {
+			t.Errorf("trade %d = %+v, want %+v", i, got[i], recordedTrades[i])
+		}
+	}
+
+	replayedReports, err := replay.StreamExecutions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, ok := <-replayedReports
+	if !ok {
+		t.Fatal("no execution replayed")
+	}
+	if report.OrderID != recordedReport.OrderID || report.Status != recordedReport.Status || report.FilledQuantity != recordedReport.FilledQuantity {
+		t.Errorf("report = %+v, want %+v", report, recordedReport)
+	}
+
+	// Each recorded call is served once
+	if _, err := replay.GetBalance("BTC"); !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("second GetBalance err = %v, want ErrNotRecorded", err)
+	}
+}
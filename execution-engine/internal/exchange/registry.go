@@ -0,0 +1,224 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+)
+
+// RegistryEventType distinguishes venue additions from removals
+type RegistryEventType int
+
+const (
+	ExchangeAdded   RegistryEventType = iota // Exchange was registered and connected
+	ExchangeRemoved                          // Exchange was unregistered and disconnected
+)
+
+func (t RegistryEventType) String() string {
+	if t == ExchangeAdded {
+		return "added"
+	}
+	return "removed"
+}
+
+// RegistryEvent reports a change in the set of registered exchanges
+type RegistryEvent struct {
+	Type     RegistryEventType
+	Exchange string
+}
+
+// registryEvents fans registry changes out to subscribers
+type registryEvents struct {
+	subs []registrySubscriber
+	mu   sync.Mutex
+}
+
+type registrySubscriber struct {
+	ch   chan RegistryEvent
+	done <-chan struct{}
+}
+
+func (e *registryEvents) subscribe(ctx context.Context) <-chan RegistryEvent {
+	ch := make(chan RegistryEvent, 16)
+
+	e.mu.Lock()
+	e.subs = append(e.subs, registrySubscriber{ch: ch, done: ctx.Done()})
+	e.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		for i, sub := range e.subs {
+			if sub.ch == ch {
+				e.subs = append(e.subs[:i], e.subs[i+1:]...)
+				close(ch)
+				break
+			}
+		}
+	}()
+
+	return ch
+}
+
+// publish delivers an event to every subscriber. Subscribers rely on seeing
+// every change, so a full subscriber blocks the publisher until it catches
+// up or its context ends.
+func (e *registryEvents) publish(ev RegistryEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, sub := range e.subs {
+		select {
+		case sub.ch <- ev:
+		case <-sub.done:
+		}
+	}
+}
+
+// Register connects an exchange and adds it to the manager. The exchange
+// is only visible to other components once it has connected.
+func (m *Manager) Register(name string, ex Interface) error {
+	if _, ok := m.GetExchange(name); ok {
+		return fmt.Errorf("%s: %w", name, ErrExchangeExists)
+	}
+	if err := ex.Connect(); err != nil {
+		return fmt.Errorf("connecting %s: %w", name, err)
+	}
+
+	m.mu.Lock()
+	if _, ok := m.exchanges[name]; ok {
+		m.mu.Unlock()
+		ex.Disconnect()
+		return fmt.Errorf("%s: %w", name, ErrExchangeExists)
+	}
+	m.exchanges[name] = ex
+	m.inflight[name] = &sync.WaitGroup{}
+	m.mu.Unlock()
+
+	log.Printf("Exchange %s registered", name)
+	m.changes.publish(RegistryEvent{Type: ExchangeAdded, Exchange: name})
+	return nil
+}
+
+// Unregister removes an exchange, waits for requests already in flight to
+// finish and disconnects it. New requests fail with ErrExchangeNotFound as
+// soon as Unregister is called. If the context ends before the venue has
+// drained it is disconnected anyway. Streams the manager opened on the
+// exchange, private ones included, are stopped with it.
+func (m *Manager) Unregister(ctx context.Context, name string) error {
+	m.mu.Lock()
+	ex, ok := m.exchanges[name]
+	if !ok {
+		m.mu.Unlock()
+		return ErrExchangeNotFound
+	}
+	inflight := m.inflight[name]
+	delete(m.exchanges, name)
+	delete(m.inflight, name)
+	m.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		inflight.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		log.Printf("Exchange %s did not drain before disconnect: %v", name, ctx.Err())
+	}
+
+	err := ex.Disconnect()
+	log.Printf("Exchange %s unregistered", name)
+	m.changes.publish(RegistryEvent{Type: ExchangeRemoved, Exchange: name})
+	return err
+}
+
+// List returns the names of all registered exchanges in sorted order
+func (m *Manager) List() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.exchanges))
+	for name := range m.exchanges {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetAllExchanges returns a snapshot of the registered exchanges
+func (m *Manager) GetAllExchanges() map[string]Interface {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	exchanges := make(map[string]Interface, len(m.exchanges))
+	for name, ex := range m.exchanges {
+		exchanges[name] = ex
+	}
+	return exchanges
+}
+
+// Changes delivers registry events until the context is cancelled
+func (m *Manager) Changes(ctx context.Context) <-chan RegistryEvent {
+	return m.changes.subscribe(ctx)
+}
+
+// acquire returns an exchange and marks a request to it as in flight, so
+// that Unregister waits for it. The returned function must be called when
+// the request completes.
+func (m *Manager) acquire(name string) (Interface, func(), bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ex, ok := m.exchanges[name]
+	if !ok {
+		return nil, nil, false
+	}
+	inflight := m.inflight[name]
+	inflight.Add(1)
+	return ex, inflight.Done, true
+}
+
+// watchExchanges calls fn for every registered exchange and for each
+// exchange registered later, until the context is cancelled. The context
+// passed to fn ends when that exchange is unregistered, so that streams
+// opened on it stop with the exchange rather than outliving it.
+func (m *Manager) watchExchanges(ctx context.Context, fn func(ctx context.Context, name string, ex Interface)) {
+	// Subscribe first so that no registration is missed
+	changes := m.Changes(ctx)
+	seen := make(map[string]Interface)
+	cancels := make(map[string]context.CancelFunc)
+	add := func(name string, ex Interface) {
+		exCtx, cancel := context.WithCancel(ctx)
+		seen[name] = ex
+		cancels[name] = cancel
+		fn(exCtx, name, ex)
+	}
+	for name, ex := range m.GetAllExchanges() {
+		add(name, ex)
+	}
+
+	go func() {
+		for ev := range changes {
+			if ev.Type == ExchangeRemoved {
+				if cancel, ok := cancels[ev.Exchange]; ok {
+					cancel()
+				}
+				delete(seen, ev.Exchange)
+				delete(cancels, ev.Exchange)
+				continue
+			}
+			ex, ok := m.GetExchange(ev.Exchange)
+			if !ok || seen[ev.Exchange] == ex {
+				continue
+			}
+			if cancel, ok := cancels[ev.Exchange]; ok {
+				cancel()
+			}
+			add(ev.Exchange, ex)
+		}
+	}()
+}
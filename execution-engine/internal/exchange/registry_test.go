@@ -0,0 +1,61 @@
+package exchange
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// contextStreamer has a private stream that, like Binance's user data
+// stream, only ends with the context it was opened on
+type contextStreamer struct {
+	*SimExchange
+	open atomic.Int32
+}
+
+func (c *contextStreamer) StreamExecutions(ctx context.Context) (<-chan ExecutionReport, error) {
+	ch := make(chan ExecutionReport)
+	c.open.Add(1)
+	go func() {
+		<-ctx.Done()
+		c.open.Add(-1)
+		close(ch)
+	}()
+	return ch, nil
+}
+
+func TestUnregisterStopsStreams(t *testing.T) {
+	ex := &contextStreamer{SimExchange: newTestSim(t, nil)}
+	m := NewManager(map[string]Interface{"sim": ex})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if _, err := m.StreamExecutions(ctx); err != nil {
+		t.Fatal(err)
+	}
+	waitFor := func(want int32) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for ex.open.Load() != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("%d execution streams open, want %d", ex.open.Load(), want)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	waitFor(1)
+
+	if err := m.Unregister(ctx, "sim"); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(0)
+
+	// Registering the same client again opens exactly one new stream
+	if err := m.Register("sim", ex); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(1)
+	time.Sleep(10 * time.Millisecond)
+	waitFor(1)
+}
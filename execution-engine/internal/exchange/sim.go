@@ -0,0 +1,793 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/trading-system/execution-engine/internal/order"
+)
+
+// PriceLevel is a single price level of an order book
+type PriceLevel struct {
+	Price    float64
+	Quantity float64
+}
+
+// SimConfig configures a simulated exchange
+type SimConfig struct {
+	MakerFee float64            // fee rate charged on resting fills
+	TakerFee float64            // fee rate charged on aggressive fills
+	Balances map[string]float64 // initial free balances per currency
+
+	// Synthetic depth rebuilt around every trade fed into the exchange.
+	// Leave DepthLevels at zero to only use liquidity set via SetLiquidity.
+	DepthLevels   int
+	DepthSpacing  float64 // relative distance between levels, e.g. 0.0005
+	DepthQuantity float64 // quantity available at each level
+}
+
+// SimFill is a single execution against one of our orders
+type SimFill struct {
+	OrderID     string
+	Symbol      string
+	Side        order.Side
+	Price       float64
+	Quantity    float64
+	Fee         float64
+	FeeCurrency string
+	Maker       bool
+	Time        time.Time
+}
+
+// SimExchange is an in-memory exchange with a price-time priority matching
+// engine. Our orders match against resting liquidity (seeded or synthetic)
+// and against trades fed in from a synthetic or replayed TradeEvent feed.
+type SimExchange struct {
+	config    SimConfig
+	books     map[string]*simBook
+	orders    map[string]*simOrder
+	fills     map[string][]SimFill
+	free      map[string]float64
+	locked    map[string]float64
+	fees      map[string]float64
+	streams   map[string][]chan TradeEvent
+	reports   []chan ExecutionReport
+	clock     time.Time
+	nextID    int64
+	mu        sync.Mutex
+	connected bool
+}
+
+type simBook struct {
+	bids      []*simOrder // best (highest) first
+	asks      []*simOrder // best (lowest) first
+	lastPrice float64
+}
+
+type simOrder struct {
+	id       string
+	symbol   string
+	side     order.Side
+	price    float64
+	quantity float64
+	filled   float64
+	cost     float64 // filled notional, for the average price
+	own      bool
+	lockRate float64 // quote locked per unit of base for resting buys
+	status   order.Status
+	seq      int64
+}
+
+func (o *simOrder) remaining() float64 {
+	return o.quantity - o.filled
+}
+
+// NewSimExchange creates a new simulated exchange
+func NewSimExchange(config SimConfig) *SimExchange {
+	s := &SimExchange{
+		config:  config,
+		books:   make(map[string]*simBook),
+		orders:  make(map[string]*simOrder),
+		fills:   make(map[string][]SimFill),
+		free:    make(map[string]float64),
+		locked:  make(map[string]float64),
+		fees:    make(map[string]float64),
+		streams: make(map[string][]chan TradeEvent),
+	}
+	for currency, amount := range config.Balances {
+		s.free[strings.ToUpper(currency)] = amount
+	}
+	return s
+}
+
+// Connect marks the simulated exchange as connected
+func (s *SimExchange) Connect() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.connected = true
+	log.Println("Connected to simulated exchange")
+	return nil
+}
+
+// Disconnect closes all trade streams
+func (s *SimExchange) Disconnect() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for symbol, subs := range s.streams {
+		for _, ch := range subs {
+			close(ch)
+		}
+		delete(s.streams, symbol)
+	}
+	for _, ch := range s.reports {
+		close(ch)
+	}
+	s.reports = nil
+	s.connected = false
+	return nil
+}
+
+// PlaceOrder matches an order against the book and rests any limit remainder
+func (s *SimExchange) PlaceOrder(ctx context.Context, o *order.Order) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.connected {
+		return "", ErrNotConnected
+	}
+	if err := checkSpotOrder(o); err != nil {
+		return "", err
+	}
+	if o.Quantity <= 0 || (o.Type == order.Limit && o.Price <= 0) {
+		return "", fmt.Errorf("%w: sim: price %v or quantity %v", ErrInvalidOrder, o.Price, o.Quantity)
+	}
+
+	symbol := NormalizeSymbol(o.Symbol)
+	base, quote := splitSymbol(symbol)
+	book := s.book(symbol)
+
+	s.nextID++
+	so := &simOrder{
+		id:       fmt.Sprintf("sim-%d", s.nextID),
+		symbol:   symbol,
+		side:     o.Side,
+		price:    o.Price,
+		quantity: o.Quantity,
+		own:      true,
+		status:   order.SentToExchange,
+		seq:      s.nextID,
+	}
+
+	// Reserve funds up front, like a venue would
+	maxFee := math.Max(s.config.MakerFee, s.config.TakerFee)
+	switch {
+	case o.Side == order.Sell:
+		if s.free[base] < o.Quantity {
+			return "", ErrInsufficientFunds
+		}
+		s.free[base] -= o.Quantity
+		s.locked[base] += o.Quantity
+	case o.Type == order.Limit:
+		so.lockRate = o.Price * (1 + maxFee)
+		required := so.lockRate * o.Quantity
+		if s.free[quote] < required {
+			return "", ErrInsufficientFunds
+		}
+		s.free[quote] -= required
+		s.locked[quote] += required
+	}
+
+	s.orders[so.id] = so
+	s.matchIncoming(book, so, o.Type == order.Market, base, quote)
+
+	if so.remaining() > 0 {
+		if o.Type == order.Market {
+			// Market remainder expires once the book is exhausted
+			s.release(so, base, quote)
+			so.status = order.Cancelled
+			s.report(so, nil)
+		} else {
+			book.insert(so)
+		}
+	}
+
+	return so.id, nil
+}
+
+// CancelOrder cancels a resting order and releases its reserved funds
+func (s *SimExchange) CancelOrder(orderID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.connected {
+		return ErrNotConnected
+	}
+
+	so, ok := s.orders[orderID]
+	if !ok {
+		return ErrOrderNotFound
+	}
+	if so.status != order.SentToExchange && so.status != order.PartiallyFilled {
+		return fmt.Errorf("%w: sim: order %s is not open", ErrOrderNotFound, orderID)
+	}
+
+	base, quote := splitSymbol(so.symbol)
+	s.book(so.symbol).remove(so)
+	s.release(so, base, quote)
+	so.status = order.Cancelled
+	s.report(so, nil)
+	return nil
+}
+
+// GetOrderStatus returns the status of one of our orders
+func (s *SimExchange) GetOrderStatus(orderID string) (order.Status, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.connected {
+		return order.Failed, ErrNotConnected
+	}
+
+	so, ok := s.orders[orderID]
+	if !ok {
+		return order.Failed, ErrOrderNotFound
+	}
+	return so.status, nil
+}
+
+// GetBalance returns the free balance of a currency
+func (s *SimExchange) GetBalance(currency string) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.connected {
+		return 0, ErrNotConnected
+	}
+	return s.free[strings.ToUpper(currency)], nil
+}
+
+// GetFees returns the configured maker and taker rates
+func (s *SimExchange) GetFees(ctx context.Context, symbol string) (*FeeSchedule, error) {
+	return &FeeSchedule{Maker: s.config.MakerFee, Taker: s.config.TakerFee}, nil
+}
+
+// GetBalances returns free and reserved funds of every currency
+func (s *SimExchange) GetBalances(ctx context.Context) ([]Balance, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.connected {
+		return nil, ErrNotConnected
+	}
+
+	now := s.now()
+	var balances []Balance
+	for currency := range s.currencies() {
+		free, locked := s.free[currency], s.locked[currency]
+		if free == 0 && locked == 0 {
+			continue
+		}
+		balances = append(balances, Balance{
+			Asset:     currency,
+			Free:      free,
+			Locked:    locked,
+			Total:     free + locked,
+			Timestamp: now,
+		})
+	}
+	return balances, nil
+}
+
+func (s *SimExchange) currencies() map[string]bool {
+	currencies := make(map[string]bool, len(s.free))
+	for currency := range s.free {
+		currencies[currency] = true
+	}
+	for currency := range s.locked {
+		currencies[currency] = true
+	}
+	return currencies
+}
+
+// StreamTrades subscribes to trades fed into or executed on the simulator
+func (s *SimExchange) StreamTrades(ctx context.Context, symbol string) (chan TradeEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.connected {
+		return nil, ErrNotConnected
+	}
+
+	symbol = NormalizeSymbol(symbol)
+	ch := make(chan TradeEvent, 1000)
+	s.streams[symbol] = append(s.streams[symbol], ch)
+
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		subs := s.streams[symbol]
+		for i, sub := range subs {
+			if sub == ch {
+				s.streams[symbol] = append(subs[:i], subs[i+1:]...)
+				close(ch)
+				break
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// StreamExecutions subscribes to fills and cancellations of our orders
+func (s *SimExchange) StreamExecutions(ctx context.Context) (<-chan ExecutionReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.connected {
+		return nil, ErrNotConnected
+	}
+
+	ch := make(chan ExecutionReport, 1000)
+	s.reports = append(s.reports, ch)
+
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for i, sub := range s.reports {
+			if sub == ch {
+				s.reports = append(s.reports[:i], s.reports[i+1:]...)
+				close(ch)
+				break
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// SetLiquidity replaces the external resting liquidity for a symbol
+func (s *SimExchange) SetLiquidity(symbol string, bids, asks []PriceLevel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	symbol = NormalizeSymbol(symbol)
+	s.setLiquidity(s.book(symbol), symbol, bids, asks)
+}
+
+// SetBalance sets the free balance of a currency
+func (s *SimExchange) SetBalance(currency string, amount float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.free[strings.ToUpper(currency)] = amount
+}
+
+// Fills returns the executions of one of our orders
+func (s *SimExchange) Fills(orderID string) []SimFill {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SimFill(nil), s.fills[orderID]...)
+}
+
+// FeesPaid returns the total fees charged per currency
+func (s *SimExchange) FeesPaid() map[string]float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fees := make(map[string]float64, len(s.fees))
+	for currency, amount := range s.fees {
+		fees[currency] = amount
+	}
+	return fees
+}
+
+// Run feeds trades into the matching engine until the feed closes or the
+// context is cancelled
+func (s *SimExchange) Run(ctx context.Context, feed <-chan TradeEvent) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-feed:
+			if !ok {
+				return
+			}
+			s.ProcessTrade(ev)
+		}
+	}
+}
+
+// ProcessTrade applies a market trade: resting orders priced through the
+// trade are filled up to its quantity, stale liquidity is swept and the
+// trade is published to subscribers
+func (s *SimExchange) ProcessTrade(ev TradeEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !ev.Timestamp.IsZero() {
+		s.clock = ev.Timestamp
+	}
+	ev.Symbol = NormalizeSymbol(ev.Symbol)
+
+	book := s.book(ev.Symbol)
+	book.lastPrice = ev.Price
+	base, quote := splitSymbol(ev.Symbol)
+
+	// The trade consumes liquidity on both sides of the book at or through its price
+	remaining := ev.Quantity
+	for _, so := range book.ownCrossedBy(ev.Price) {
+		if remaining <= 0 {
+			break
+		}
+		qty := math.Min(so.remaining(), remaining)
+		s.fill(so, so.price, qty, true, base, quote)
+		remaining -= qty
+		if so.remaining() <= 0 {
+			book.remove(so)
+		}
+	}
+	book.sweepExternal(ev.Price)
+
+	if s.config.DepthLevels > 0 {
+		s.rebuildDepth(book, ev.Symbol, ev.Price)
+	}
+
+	s.publish(ev)
+}
+
+func (s *SimExchange) book(symbol string) *simBook {
+	book, ok := s.books[symbol]
+	if !ok {
+		book = &simBook{}
+		s.books[symbol] = book
+	}
+	return book
+}
+
+// matchIncoming executes an incoming order against external liquidity on
+// the opposite side. Our own resting orders are skipped (self-trade
+// prevention).
+func (s *SimExchange) matchIncoming(book *simBook, so *simOrder, market bool, base, quote string) {
+	levels := book.asks
+	if so.side == order.Sell {
+		levels = book.bids
+	}
+
+	for _, resting := range append([]*simOrder(nil), levels...) {
+		if so.remaining() <= 0 {
+			break
+		}
+		if resting.own {
+			continue
+		}
+		if !market {
+			if so.side == order.Buy && resting.price > so.price {
+				break
+			}
+			if so.side == order.Sell && resting.price < so.price {
+				break
+			}
+		}
+
+		qty := math.Min(so.remaining(), resting.remaining())
+		if market && so.side == order.Buy {
+			// Market buys are bounded by the quote currency on hand
+			affordable := s.free[quote] / (resting.price * (1 + s.config.TakerFee))
+			qty = math.Min(qty, affordable)
+			if qty <= 0 {
+				break
+			}
+		}
+
+		s.fill(so, resting.price, qty, false, base, quote)
+		resting.filled += qty
+		if resting.remaining() <= 0 {
+			book.remove(resting)
+		}
+		book.lastPrice = resting.price
+
+		s.publish(TradeEvent{
+			Symbol:    so.symbol,
+			Price:     resting.price,
+			Quantity:  qty,
+			Timestamp: s.now(),
+		})
+	}
+}
+
+// fill settles an execution of one of our orders
+func (s *SimExchange) fill(so *simOrder, price, qty float64, maker bool, base, quote string) {
+	rate := s.config.TakerFee
+	if maker {
+		rate = s.config.MakerFee
+	}
+	notional := price * qty
+	fee := notional * rate
+
+	if so.side == order.Buy {
+		if so.lockRate > 0 {
+			reserved := so.lockRate * qty
+			s.locked[quote] -= reserved
+			s.free[quote] += reserved
+		}
+		s.free[quote] -= notional + fee
+		s.free[base] += qty
+	} else {
+		s.locked[base] -= qty
+		s.free[quote] += notional - fee
+	}
+	s.fees[quote] += fee
+
+	so.filled += qty
+	so.cost += notional
+	if so.remaining() <= 1e-12 {
+		so.filled = so.quantity
+		so.status = order.Filled
+	} else {
+		so.status = order.PartiallyFilled
+	}
+
+	f := SimFill{
+		OrderID:     so.id,
+		Symbol:      so.symbol,
+		Side:        so.side,
+		Price:       price,
+		Quantity:    qty,
+		Fee:         fee,
+		FeeCurrency: quote,
+		Maker:       maker,
+		Time:        s.now(),
+	}
+	s.fills[so.id] = append(s.fills[so.id], f)
+	s.report(so, &f)
+}
+
+// report publishes an execution report for one of our orders, carrying the
+// fill if there was one
+func (s *SimExchange) report(so *simOrder, f *SimFill) {
+	r := ExecutionReport{
+		OrderID:        so.id,
+		Symbol:         so.symbol,
+		Side:           so.side,
+		Status:         so.status,
+		FilledQuantity: so.filled,
+		Timestamp:      s.now(),
+	}
+	if so.filled > 0 {
+		r.AveragePrice = so.cost / so.filled
+	}
+	if f != nil {
+		r.LastFillPrice = f.Price
+		r.LastFillQuantity = f.Quantity
+		r.TradeID = fmt.Sprintf("%s-%d", so.id, len(s.fills[so.id]))
+		r.Fee = f.Fee
+		r.FeeCurrency = f.FeeCurrency
+	}
+
+	for _, ch := range s.reports {
+		select {
+		case ch <- r:
+		default:
+			log.Printf("Simulated execution stream full, dropping report for %s", so.id)
+		}
+	}
+}
+
+// release returns the funds reserved for the unfilled part of an order
+func (s *SimExchange) release(so *simOrder, base, quote string) {
+	remaining := so.remaining()
+	if so.side == order.Sell {
+		s.locked[base] -= remaining
+		s.free[base] += remaining
+	} else if so.lockRate > 0 {
+		reserved := so.lockRate * remaining
+		s.locked[quote] -= reserved
+		s.free[quote] += reserved
+	}
+}
+
+func (s *SimExchange) setLiquidity(book *simBook, symbol string, bids, asks []PriceLevel) {
+	book.bids = ownOnly(book.bids)
+	book.asks = ownOnly(book.asks)
+
+	for _, lvl := range bids {
+		s.nextID++
+		book.insert(&simOrder{symbol: symbol, side: order.Buy, price: lvl.Price, quantity: lvl.Quantity, seq: s.nextID})
+	}
+	for _, lvl := range asks {
+		s.nextID++
+		book.insert(&simOrder{symbol: symbol, side: order.Sell, price: lvl.Price, quantity: lvl.Quantity, seq: s.nextID})
+	}
+}
+
+func (s *SimExchange) rebuildDepth(book *simBook, symbol string, price float64) {
+	bids := make([]PriceLevel, 0, s.config.DepthLevels)
+	asks := make([]PriceLevel, 0, s.config.DepthLevels)
+	for i := 1; i <= s.config.DepthLevels; i++ {
+		offset := s.config.DepthSpacing * float64(i)
+		bids = append(bids, PriceLevel{Price: price * (1 - offset), Quantity: s.config.DepthQuantity})
+		asks = append(asks, PriceLevel{Price: price * (1 + offset), Quantity: s.config.DepthQuantity})
+	}
+	s.setLiquidity(book, symbol, bids, asks)
+}
+
+// publish fans a trade out to subscribers without blocking the engine
+func (s *SimExchange) publish(ev TradeEvent) {
+	for _, ch := range s.streams[ev.Symbol] {
+		select {
+		case ch <- ev:
+		default:
+			log.Printf("Simulated stream full for %s, dropping trade", ev.Symbol)
+		}
+	}
+}
+
+// now returns the simulation clock, which follows replayed trade timestamps
+func (s *SimExchange) now() time.Time {
+	if s.clock.IsZero() {
+		return time.Now()
+	}
+	return s.clock
+}
+
+func (b *simBook) insert(so *simOrder) {
+	if so.side == order.Buy {
+		b.bids = append(b.bids, so)
+		sort.SliceStable(b.bids, func(i, j int) bool {
+			if b.bids[i].price != b.bids[j].price {
+				return b.bids[i].price > b.bids[j].price
+			}
+			return b.bids[i].seq < b.bids[j].seq
+		})
+		return
+	}
+	b.asks = append(b.asks, so)
+	sort.SliceStable(b.asks, func(i, j int) bool {
+		if b.asks[i].price != b.asks[j].price {
+			return b.asks[i].price < b.asks[j].price
+		}
+		return b.asks[i].seq < b.asks[j].seq
+	})
+}
+
+func (b *simBook) remove(so *simOrder) {
+	b.bids = removeOrder(b.bids, so)
+	b.asks = removeOrder(b.asks, so)
+}
+
+// ownCrossedBy returns our resting orders that a trade at price executes
+// against, in priority order
+func (b *simBook) ownCrossedBy(price float64) []*simOrder {
+	var crossed []*simOrder
+	for _, so := range b.bids {
+		if so.own && so.price >= price {
+			crossed = append(crossed, so)
+		}
+	}
+	for _, so := range b.asks {
+		if so.own && so.price <= price {
+			crossed = append(crossed, so)
+		}
+	}
+	return crossed
+}
+
+// sweepExternal drops external liquidity the market has traded through
+func (b *simBook) sweepExternal(price float64) {
+	bids := b.bids[:0]
+	for _, so := range b.bids {
+		if so.own || so.price <= price {
+			bids = append(bids, so)
+		}
+	}
+	b.bids = bids
+
+	asks := b.asks[:0]
+	for _, so := range b.asks {
+		if so.own || so.price >= price {
+			asks = append(asks, so)
+		}
+	}
+	b.asks = asks
+}
+
+func removeOrder(orders []*simOrder, target *simOrder) []*simOrder {
+	for i, so := range orders {
+		if so == target {
+			return append(orders[:i], orders[i+1:]...)
+		}
+	}
+	return orders
+}
+
+func ownOnly(orders []*simOrder) []*simOrder {
+	kept := orders[:0]
+	for _, so := range orders {
+		if so.own {
+			kept = append(kept, so)
+		}
+	}
+	return kept
+}
+
+// SyntheticFeed generates a random-walk trade feed for a symbol
+type SyntheticFeed struct {
+	Symbol       string
+	StartPrice   float64
+	Volatility   float64 // standard deviation of the relative price change per trade
+	MeanQuantity float64
+	Interval     time.Duration
+	Seed         int64 // identical seeds produce identical feeds
+}
+
+// Run starts generating trades until the context is cancelled
+func (f SyntheticFeed) Run(ctx context.Context) <-chan TradeEvent {
+	out := make(chan TradeEvent, 100)
+	rng := rand.New(rand.NewSource(f.Seed))
+
+	go func() {
+		defer close(out)
+		ticker := time.NewTicker(f.Interval)
+		defer ticker.Stop()
+
+		price := f.StartPrice
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				price *= 1 + rng.NormFloat64()*f.Volatility
+				ev := TradeEvent{
+					Symbol:    f.Symbol,
+					Price:     price,
+					Quantity:  rng.ExpFloat64() * f.MeanQuantity,
+					Timestamp: now,
+				}
+				select {
+				case out <- ev:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// ReplayTrades replays recorded trades, preserving the gaps between their
+// timestamps divided by speed. A speed of zero replays as fast as possible.
+func ReplayTrades(ctx context.Context, events []TradeEvent, speed float64) <-chan TradeEvent {
+	out := make(chan TradeEvent, 100)
+
+	go func() {
+		defer close(out)
+		for i, ev := range events {
+			if speed > 0 && i > 0 {
+				gap := ev.Timestamp.Sub(events[i-1].Timestamp)
+				if gap > 0 {
+					select {
+					case <-time.After(time.Duration(float64(gap) / speed)):
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+			select {
+			case out <- ev:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
@@ -0,0 +1,156 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/trading-system/execution-engine/internal/order"
+)
+
+func newTestSim(t *testing.T, balances map[string]float64) *SimExchange {
+	t.Helper()
+	s := NewSimExchange(SimConfig{MakerFee: 0.0005, TakerFee: 0.001, Balances: balances})
+	if err := s.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func assertClose(t *testing.T, name string, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 1e-9 {
+		t.Errorf("%s = %v, want %v", name, got, want)
+	}
+}
+
+func simBalance(t *testing.T, s *SimExchange, currency string) (free, locked float64) {
+	t.Helper()
+	balances, err := s.GetBalances(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range balances {
+		if b.Asset == currency {
+			return b.Free, b.Locked
+		}
+	}
+	return 0, 0
+}
+
+func TestSimLimitBuyPartialFill(t *testing.T) {
+	s := newTestSim(t, map[string]float64{"USDT": 1000})
+	s.SetLiquidity("BTC/USDT", nil, []PriceLevel{{Price: 100, Quantity: 1}, {Price: 101, Quantity: 2}})
+
+	ctx := context.Background()
+	reports, err := s.StreamExecutions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Takes the 100 level, the rest rests at 100.5 below the 101 ask
+	id, err := s.PlaceOrder(ctx, &order.Order{Symbol: "BTC/USDT", Type: order.Limit, Side: order.Buy, Price: 100.5, Quantity: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := s.GetOrderStatus(id); status != order.PartiallyFilled {
+		t.Errorf("status = %v, want PartiallyFilled", status)
+	}
+
+	// 2 @ 100.5 plus the highest fee rate is reserved; the taker fill
+	// releases its share and pays 100 plus 0.1 fee
+	free, locked := simBalance(t, s, "USDT")
+	assertClose(t, "free USDT", free, 1000-201.201+100.6005-100.1)
+	assertClose(t, "locked USDT", locked, 100.6005)
+	btc, _ := simBalance(t, s, "BTC")
+	assertClose(t, "free BTC", btc, 1)
+
+	// A trade through the resting price fills the remainder as maker
+	s.ProcessTrade(TradeEvent{Symbol: "BTC/USDT", Price: 100.4, Quantity: 5})
+	if status, _ := s.GetOrderStatus(id); status != order.Filled {
+		t.Errorf("status = %v, want Filled", status)
+	}
+
+	free, locked = simBalance(t, s, "USDT")
+	assertClose(t, "free USDT", free, 1000-100.1-100.55025)
+	assertClose(t, "locked USDT", locked, 0)
+	btc, _ = simBalance(t, s, "BTC")
+	assertClose(t, "free BTC", btc, 2)
+	assertClose(t, "fees paid", s.FeesPaid()["USDT"], 0.1+0.05025)
+
+	fills := s.Fills(id)
+	if len(fills) != 2 {
+		t.Fatalf("got %d fills, want 2", len(fills))
+	}
+	if fills[0].Maker || !fills[1].Maker {
+		t.Errorf("maker flags = %v, %v, want false, true", fills[0].Maker, fills[1].Maker)
+	}
+	assertClose(t, "taker fill price", fills[0].Price, 100)
+	assertClose(t, "maker fill price", fills[1].Price, 100.5)
+
+	var last ExecutionReport
+	for i := 0; i < 2; i++ {
+		last = <-reports
+		if last.OrderID != id || last.LastFillQuantity != 1 {
+			t.Errorf("unexpected report %+v", last)
+		}
+	}
+	if last.Status != order.Filled || last.FilledQuantity != 2 {
+		t.Errorf("final report status %v filled %v, want Filled 2", last.Status, last.FilledQuantity)
+	}
+	assertClose(t, "average price", last.AveragePrice, 100.25)
+	assertClose(t, "report fee", last.Fee, 0.05025)
+}
+
+func TestSimMarketSellExhaustsBook(t *testing.T) {
+	s := newTestSim(t, map[string]float64{"BTC": 3})
+	s.SetLiquidity("BTC/USDT", []PriceLevel{{Price: 99, Quantity: 1}}, nil)
+
+	id, err := s.PlaceOrder(context.Background(), &order.Order{Symbol: "BTC/USDT", Type: order.Market, Side: order.Sell, Quantity: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The unfilled remainder expires and its base is released
+	if status, _ := s.GetOrderStatus(id); status != order.Cancelled {
+		t.Errorf("status = %v, want Cancelled", status)
+	}
+	free, locked := simBalance(t, s, "BTC")
+	assertClose(t, "free BTC", free, 2)
+	assertClose(t, "locked BTC", locked, 0)
+	usdt, _ := simBalance(t, s, "USDT")
+	assertClose(t, "free USDT", usdt, 99-0.099)
+	assertClose(t, "fees paid", s.FeesPaid()["USDT"], 0.099)
+}
+
+func TestSimCancelReleasesFunds(t *testing.T) {
+	s := newTestSim(t, map[string]float64{"USDT": 100})
+	ctx := context.Background()
+
+	if _, err := s.PlaceOrder(ctx, &order.Order{Symbol: "BTC/USDT", Type: order.Limit, Side: order.Buy, Price: 100, Quantity: 1}); !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("err = %v, want ErrInsufficientFunds", err)
+	}
+
+	id, err := s.PlaceOrder(ctx, &order.Order{Symbol: "BTC/USDT", Type: order.Limit, Side: order.Buy, Price: 50, Quantity: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, locked := simBalance(t, s, "USDT"); locked == 0 {
+		t.Error("no funds reserved for resting order")
+	}
+
+	if err := s.CancelOrder(id); err != nil {
+		t.Fatal(err)
+	}
+	free, locked := simBalance(t, s, "USDT")
+	assertClose(t, "free USDT", free, 100)
+	assertClose(t, "locked USDT", locked, 0)
+
+	if err := s.CancelOrder(id); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("second cancel err = %v, want ErrOrderNotFound", err)
+	}
+	if _, err := s.PlaceOrder(ctx, &order.Order{Symbol: "BTC/USDT", Type: order.Limit, Side: order.Buy, Quantity: 1}); !errors.Is(err, ErrInvalidOrder) {
+		t.Errorf("zero price err = %v, want ErrInvalidOrder", err)
+	}
+}
@@ -0,0 +1,143 @@
+package exchange
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MarketType distinguishes spot markets from derivatives
+type MarketType int
+
+const (
+	Spot      MarketType = iota // Spot market
+	Perpetual                   // Perpetual swap
+)
+
+// perpetualSuffix marks perpetual markets in canonical symbol strings
+const perpetualSuffix = ":PERP"
+
+// knownQuotes lists quote currencies used to split concatenated symbols,
+// longest first so that USDT is not mistaken for USD
+var knownQuotes = []string{"USDT", "USDC", "BUSD", "USD", "EUR", "GBP", "BTC", "ETH"}
+
+// assetAliases maps venue specific asset codes onto their common names
+var assetAliases = map[string]string{
+	"XBT": "BTC",
+	"XDG": "DOGE",
+}
+
+// Symbol is the venue independent identifier of an instrument. Its string
+// form (BTC/USDT, BTC/USDT:PERP) is what strategies, orders and the stream
+// aggregator use; adapters translate it to native names at the edge.
+type Symbol struct {
+	Base   string
+	Quote  string
+	Market MarketType
+}
+
+// String returns the canonical form of the symbol
+func (s Symbol) String() string {
+	str := s.Base + "/" + s.Quote
+	if s.Market == Perpetual {
+		str += perpetualSuffix
+	}
+	return str
+}
+
+// ParseSymbol parses a canonical symbol. For convenience it also accepts
+// BTC-USDT and concatenated BTCUSDT forms, and normalizes aliases like XBT.
+func ParseSymbol(str string) (Symbol, error) {
+	s := Symbol{Market: Spot}
+	str = strings.ToUpper(strings.TrimSpace(str))
+	if strings.HasSuffix(str, perpetualSuffix) {
+		s.Market = Perpetual
+		str = strings.TrimSuffix(str, perpetualSuffix)
+	}
+
+	for _, sep := range []string{"/", "-"} {
+		if parts := strings.SplitN(str, sep, 2); len(parts) == 2 {
+			s.Base, s.Quote = parts[0], parts[1]
+			break
+		}
+	}
+	if s.Base == "" {
+		for _, quote := range knownQuotes {
+			if strings.HasSuffix(str, quote) && len(str) > len(quote) {
+				s.Base, s.Quote = strings.TrimSuffix(str, quote), quote
+				break
+			}
+		}
+	}
+	if s.Base == "" || s.Quote == "" {
+		return Symbol{}, fmt.Errorf("unrecognised symbol %q", str)
+	}
+
+	if alias, ok := assetAliases[s.Base]; ok {
+		s.Base = alias
+	}
+	if alias, ok := assetAliases[s.Quote]; ok {
+		s.Quote = alias
+	}
+	return s, nil
+}
+
+// NormalizeSymbol returns the canonical form of a symbol string, or the
+// string unchanged if it cannot be parsed
+func NormalizeSymbol(symbol string) string {
+	s, err := ParseSymbol(symbol)
+	if err != nil {
+		return symbol
+	}
+	return s.String()
+}
+
+// SymbolMapper is implemented by adapters to translate canonical symbols to
+// and from the venue's native names
+type SymbolMapper interface {
+	ToExchange(s Symbol) string
+	FromExchange(native string) (Symbol, error)
+}
+
+// nativeSymbol translates a symbol string for a venue. Strings that are not
+// canonical are passed through so venue-native names keep working.
+func nativeSymbol(m SymbolMapper, symbol string) string {
+	s, err := ParseSymbol(symbol)
+	if err != nil {
+		return symbol
+	}
+	return m.ToExchange(s)
+}
+
+// splitSymbol returns the base and quote currency of a symbol
+func splitSymbol(symbol string) (string, string) {
+	s, err := ParseSymbol(symbol)
+	if err != nil {
+		return strings.ToUpper(symbol), ""
+	}
+	return s.Base, s.Quote
+}
+
+// NativeSymbol translates a canonical symbol into an exchange's native name
+func (m *Manager) NativeSymbol(exchangeName, symbol string) string {
+	ex, ok := m.GetExchange(exchangeName)
+	if !ok {
+		return symbol
+	}
+	mapper, ok := ex.(SymbolMapper)
+	if !ok {
+		return symbol
+	}
+	return nativeSymbol(mapper, symbol)
+}
+
+// CanonicalSymbol translates an exchange's native name into a canonical symbol
+func (m *Manager) CanonicalSymbol(exchangeName, native string) (Symbol, error) {
+	ex, ok := m.GetExchange(exchangeName)
+	if !ok {
+		return Symbol{}, ErrExchangeNotFound
+	}
+	if mapper, ok := ex.(SymbolMapper); ok {
+		return mapper.FromExchange(native)
+	}
+	return ParseSymbol(native)
+}
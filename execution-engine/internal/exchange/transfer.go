@@ -0,0 +1,173 @@
+package exchange
+
+import (
+	"context"
+	"time"
+)
+
+// TransferStatus is the state of a withdrawal or deposit
+type TransferStatus int
+
+const (
+	TransferPending    TransferStatus = iota // Requested, awaiting approval or broadcast
+	TransferProcessing                       // Broadcast, awaiting confirmations
+	TransferCompleted                        // Settled on the destination
+	TransferFailed                           // Rejected by the venue or the network
+	TransferCancelled                        // Cancelled before it was sent
+)
+
+func (s TransferStatus) String() string {
+	switch s {
+	case TransferPending:
+		return "pending"
+	case TransferProcessing:
+		return "processing"
+	case TransferCompleted:
+		return "completed"
+	case TransferFailed:
+		return "failed"
+	default:
+		return "cancelled"
+	}
+}
+
+// Done reports whether no further updates are expected for the status
+func (s TransferStatus) Done() bool {
+	return s == TransferCompleted || s == TransferFailed || s == TransferCancelled
+}
+
+// DepositAddress is where an exchange accepts deposits of an asset
+type DepositAddress struct {
+	Asset   string
+	Network string
+	Address string
+	Tag     string // memo or destination tag, if the network needs one
+}
+
+// Withdrawal is a request to send funds off an exchange
+type Withdrawal struct {
+	Asset   string
+	Network string // empty for the asset's default network
+	Address string
+	Tag     string
+	Amount  float64
+	// Key names a pre-approved withdrawal address, for venues that only
+	// withdraw to addresses configured in the account (Kraken)
+	Key string
+}
+
+// Transfer is a withdrawal or deposit as reported by an exchange
+type Transfer struct {
+	ID        string // venue reference
+	Exchange  string
+	Asset     string
+	Network   string
+	Address   string
+	Amount    float64
+	Fee       float64
+	TxID      string // on-chain transaction, once broadcast
+	Status    TransferStatus
+	Timestamp time.Time
+}
+
+// TransferProvider is implemented by exchanges that can move funds on and
+// off the venue
+type TransferProvider interface {
+	// GetDepositAddress returns the deposit address of an asset. An empty
+	// network selects the asset's default network.
+	GetDepositAddress(ctx context.Context, asset, network string) (*DepositAddress, error)
+	// Withdraw submits a withdrawal and returns its venue reference
+	Withdraw(ctx context.Context, w Withdrawal) (string, error)
+	// GetWithdrawal returns the current state of a withdrawal
+	GetWithdrawal(ctx context.Context, asset, id string) (*Transfer, error)
+	// GetDeposits returns deposits of an asset made since the given time
+	GetDeposits(ctx context.Context, asset string, since time.Time) ([]Transfer, error)
+}
+
+// DepositSweeper is implemented by exchanges that credit deposits to a
+// wallet other than the one they trade from (Binance credits spot, the
+// engine trades futures)
+type DepositSweeper interface {
+	// SweepDeposit moves a credited deposit into the trading wallet
+	SweepDeposit(ctx context.Context, asset string, amount float64) error
+}
+
+func (m *Manager) transfers(exchangeName string) (TransferProvider, error) {
+	ex, ok := m.GetExchange(exchangeName)
+	if !ok {
+		return nil, ErrExchangeNotFound
+	}
+	provider, ok := ex.(TransferProvider)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return provider, nil
+}
+
+// GetDepositAddress returns the deposit address of an asset on the specified exchange
+func (m *Manager) GetDepositAddress(ctx context.Context, exchangeName, asset, network string) (*DepositAddress, error) {
+	provider, err := m.transfers(exchangeName)
+	if err != nil {
+		return nil, err
+	}
+	return provider.GetDepositAddress(ctx, asset, network)
+}
+
+// Withdraw submits a withdrawal from the specified exchange. Withdrawals are
+// not retried: a timeout may still have moved funds, so callers must check
+// the withdrawal history before trying again.
+func (m *Manager) Withdraw(ctx context.Context, exchangeName string, w Withdrawal) (string, error) {
+	provider, err := m.transfers(exchangeName)
+	if err != nil {
+		return "", err
+	}
+	if w.Amount <= 0 || w.Asset == "" || (w.Address == "" && w.Key == "") {
+		return "", ErrInvalidTransfer
+	}
+	return provider.Withdraw(ctx, w)
+}
+
+// GetWithdrawal returns the state of a withdrawal on the specified exchange
+func (m *Manager) GetWithdrawal(ctx context.Context, exchangeName, asset, id string) (*Transfer, error) {
+	provider, err := m.transfers(exchangeName)
+	if err != nil {
+		return nil, err
+	}
+	t, err := provider.GetWithdrawal(ctx, asset, id)
+	if err != nil {
+		return nil, err
+	}
+	t.Exchange = exchangeName
+	return t, nil
+}
+
+// GetDeposits returns recent deposits of an asset on the specified exchange
+func (m *Manager) GetDeposits(ctx context.Context, exchangeName, asset string, since time.Time) ([]Transfer, error) {
+	provider, err := m.transfers(exchangeName)
+	if err != nil {
+		return nil, err
+	}
+	deposits, err := provider.GetDeposits(ctx, asset, since)
+	if err != nil {
+		return nil, err
+	}
+	for i := range deposits {
+		deposits[i].Exchange = exchangeName
+	}
+	return deposits, nil
+}
+
+// SweepDeposit makes a credited deposit on the specified exchange available
+// for trading. Exchanges that credit deposits to the trading wallet need
+// nothing done.
+func (m *Manager) SweepDeposit(ctx context.Context, exchangeName, asset string, amount float64) error {
+	ex, ok := m.GetExchange(exchangeName)
+	if !ok {
+		return ErrExchangeNotFound
+	}
+	sweeper, ok := ex.(DepositSweeper)
+	if !ok {
+		return nil
+	}
+	return sweeper.SweepDeposit(ctx, asset, amount)
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
+	"math"
+	"strconv"
 	"sync"
 	"time"
 
@@ -25,6 +28,29 @@ type Order struct {
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
 	RetryCount int
+
+	// Derivatives parameters, rejected by spot venues
+	ReduceOnly   bool
+	PositionSide PositionSide
+
+	// Execution state, maintained from the exchanges' private streams
+	FilledQuantity float64
+	AvgFillPrice   float64
+	Fee            float64
+	EstimatedFee   float64 // expected fee at the limit price, set before placement
+
+	// Exchange order IDs the order had before being replaced by ModifyOrder
+	ReplacedIDs []string
+}
+
+// PriceString formats the price for exchange APIs
+func (o *Order) PriceString() string {
+	return strconv.FormatFloat(o.Price, 'f', -1, 64)
+}
+
+// QuantityString formats the quantity for exchange APIs
+func (o *Order) QuantityString() string {
+	return strconv.FormatFloat(o.Quantity, 'f', -1, 64)
 }
 
 // Type represents order type
@@ -43,6 +69,15 @@ const (
 	Sell             // Sell order
 )
 
+// PositionSide selects the position an order applies to in hedge mode
+type PositionSide int
+
+const (
+	PositionBoth  PositionSide = iota // One-way mode, the default
+	PositionLong                      // Long side of a hedge mode account
+	PositionShort                     // Short side of a hedge mode account
+)
+
 // Status represents order status
 type Status int
 
@@ -56,16 +91,47 @@ const (
 	Rejected
 )
 
+// terminal reports whether no further updates are expected for the status
+func (s Status) terminal() bool {
+	return s == Filled || s == Cancelled || s == Failed || s == Rejected
+}
+
+// pendingReportTTL bounds how long execution reports for unknown orders are
+// kept while waiting for placement to return
+const pendingReportTTL = time.Minute
+
+// orderStore records orders and their fills
+type orderStore interface {
+	LogOrder(o *Order) error
+	LogTrade(t *db.Trade) error
+}
+
 // Manager handles order processing
 type Manager struct {
 	exchangeManager *exchange.Manager
 	riskClient      *risk.Client
-	db             *db.TimescaleDB
+	db             orderStore
 	slippageProtection bool
 	maxRetries      int
 	retryDelay      time.Duration
 	orderChan       chan *Order
-	mu              sync.Mutex
+	mu              sync.Mutex // guards slippageProtection
+
+	// Open orders by exchange and exchange order ID, plus reports that
+	// arrived before their order's placement call returned
+	open    map[string]*Order
+	pending map[string][]exchange.ExecutionReport
+	openMu  sync.Mutex
+
+	// Quantity filled per exchange order ID, since a replaced order fills
+	// across several, and orders with a modification in flight, set once
+	// their cancellation has been held back
+	legs      map[string]float64
+	modifying map[string]bool
+
+	// Trade IDs applied per exchange order ID, so that repeated fill
+	// reports are only counted once
+	trades map[string]map[string]bool
 }
 
 // NewManager creates a new order manager
@@ -81,11 +147,18 @@ func NewManager(
 		maxRetries:      3,
 		retryDelay:      500 * time.Millisecond,
 		orderChan:       make(chan *Order, 1000),
+		open:            make(map[string]*Order),
+		pending:         make(map[string][]exchange.ExecutionReport),
+		legs:            make(map[string]float64),
+		modifying:       make(map[string]bool),
+		trades:          make(map[string]map[string]bool),
 	}
 }
 
 // SetSlippageProtection enables/disables slippage protection
 func (m *Manager) SetSlippageProtection(enabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.slippageProtection = enabled
 }
 
@@ -109,17 +182,154 @@ func (m *Manager) ProcessOrders(ctx context.Context) {
 }
 
 func (m *Manager) processOrder(ctx context.Context, o *Order) {
+	if !m.prepareOrder(ctx, o) {
+		return
+	}
+
+	// Execute with retry logic
+	var retryAfter time.Duration
+	for i := 0; i <= m.maxRetries; i++ {
+		o.RetryCount = i
+		if i > 0 {
+			// Don't retry into a venue that asked us to slow down
+			if !sleep(ctx, max(m.retryDelay*time.Duration(i), retryAfter)) {
+				return
+			}
+		}
+
+		// Place order through the manager so the venue's circuit breaker applies
+		exchangeID, err := m.exchangeManager.PlaceOrder(ctx, o.Exchange, o)
+		if err != nil {
+			retry, wait := m.placementFailed(o, i, err)
+			if !retry {
+				return
+			}
+			retryAfter = wait
+			continue
+		}
+
+		m.sent(o, exchangeID)
+		return
+	}
+}
+
+// PlaceOrders places a group of orders immediately, in native batches on
+// venues that support them, retrying the orders that fail. Each order is
+// checked and updated as if it had been submitted on its own.
+func (m *Manager) PlaceOrders(ctx context.Context, orders []*Order) {
+	remaining := make([]*Order, 0, len(orders))
+	for _, o := range orders {
+		o.Status = Pending
+		o.CreatedAt = time.Now()
+		if m.prepareOrder(ctx, o) {
+			remaining = append(remaining, o)
+		}
+	}
+
+	var retryAfter time.Duration
+	for i := 0; i <= m.maxRetries && len(remaining) > 0; i++ {
+		if i > 0 {
+			if !sleep(ctx, max(m.retryDelay*time.Duration(i), retryAfter)) {
+				return
+			}
+			retryAfter = 0
+		}
+
+		byExchange := make(map[string][]*Order)
+		for _, o := range remaining {
+			o.RetryCount = i
+			byExchange[o.Exchange] = append(byExchange[o.Exchange], o)
+		}
+
+		remaining = remaining[:0]
+		for exchangeName, group := range byExchange {
+			results, err := m.exchangeManager.PlaceOrders(ctx, exchangeName, group)
+			for j, o := range group {
+				if err == nil && results[j].Err == nil {
+					m.sent(o, results[j].ID)
+					continue
+				}
+				failure := err
+				if failure == nil {
+					failure = results[j].Err
+				}
+				if retry, wait := m.placementFailed(o, i, failure); retry {
+					retryAfter = max(retryAfter, wait)
+					remaining = append(remaining, o)
+				}
+			}
+		}
+	}
+}
+
+// placementFailed records a failed placement attempt and reports whether
+// the classification of the error allows another attempt, and the least
+// time to wait before it. Placements on an unavailable venue are deferred
+// until its breaker would let them through.
+func (m *Manager) placementFailed(o *Order, attempt int, err error) (bool, time.Duration) {
+	class, wait := exchange.Classify(err)
+	switch {
+	case errors.Is(err, exchange.ErrExchangeNotFound):
+		o.Status = Failed
+		m.logOrder(o, fmt.Sprintf("Exchange not found: %s", o.Exchange))
+	case class == exchange.RetryUnavailable:
+		// Wait for the breaker to let a probe through before trying again
+		wait = max(wait, m.exchangeManager.BreakerConfig().OpenTimeout)
+		o.Status = Failed
+		m.logOrder(o, fmt.Sprintf("Exchange unavailable, retrying in %v: %v", wait, err))
+		return true, wait
+	case class == exchange.RetryNever:
+		o.Status = Rejected
+		m.logOrder(o, fmt.Sprintf("Rejected by exchange: %v", err))
+	case class == exchange.RetryUnsafe:
+		// Placing it again could duplicate the order
+		o.Status = Failed
+		m.logOrder(o, fmt.Sprintf("Placement outcome unknown, not retried: %v", err))
+	default:
+		o.Status = Failed
+		m.logOrder(o, fmt.Sprintf("Placement attempt %d failed: %v", attempt+1, err))
+		return true, wait
+	}
+	return false, 0
+}
+
+// sleep waits for d unless the context ends first, reporting whether it
+// waited the whole time
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// sent records a successful placement and starts tracking the order
+func (m *Manager) sent(o *Order, exchangeID string) {
+	o.ID = exchangeID
+	o.Status = SentToExchange
+	m.logOrder(o, fmt.Sprintf("Order sent to exchange, estimated fee %v", o.EstimatedFee))
+	m.track(o)
+}
+
+// prepareOrder applies slippage protection and the venue's trading rules,
+// estimates fees and runs the risk check, reporting whether the order may
+// be placed
+func (m *Manager) prepareOrder(ctx context.Context, o *Order) bool {
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	slippageProtection := m.slippageProtection
+	m.mu.Unlock()
 
 	// Apply slippage protection for limit orders
-	if m.slippageProtection && o.Type == Limit {
+	if slippageProtection && o.Type == Limit {
 		// Get current market price
 		marketPrice, err := m.getMarketPrice(o.Symbol)
 		if err != nil {
 			o.Status = Failed
 			m.logOrder(o, fmt.Sprintf("Failed to get market price: %v", err))
-			return
+			return false
 		}
 
 		// Adjust price if necessary
@@ -130,47 +340,271 @@ func (m *Manager) processOrder(ctx context.Context, o *Order) {
 		}
 	}
 
+	// Align price and quantity with the venue's tick, lot and notional filters
+	if inst, ok := m.exchangeManager.GetInstrument(o.Exchange, o.Symbol); ok {
+		if err := inst.Normalize(o); err != nil {
+			o.Status = Rejected
+			m.logOrder(o, fmt.Sprintf("Order violates %s trading rules: %v", o.Exchange, err))
+			return false
+		}
+	}
+
+	// Estimate fees from the account's tier so the cost is known up front
+	if fee, err := m.exchangeManager.EstimateFee(ctx, o); err == nil {
+		o.EstimatedFee = fee
+	}
+
 	// Check with risk controller
 	riskApproved, err := m.riskClient.CheckOrder(o)
 	if err != nil {
 		o.Status = Failed
 		m.logOrder(o, fmt.Sprintf("Risk check failed: %v", err))
-		return
+		return false
 	}
 	if !riskApproved {
 		o.Status = Rejected
 		m.logOrder(o, "Rejected by risk controller")
-		return
+		return false
 	}
+	return true
+}
 
-	// Execute with retry logic
-	for i := 0; i <= m.maxRetries; i++ {
-		o.RetryCount = i
-		if i > 0 {
-			time.Sleep(m.retryDelay * time.Duration(i))
+// ModifyOrder changes the price and quantity of a working limit order. The
+// quantity is the new total, including what has already filled. When the
+// venue cancels and replaces the order, the order takes the replacement's
+// exchange ID and keeps applying fills reported for the old one.
+func (m *Manager) ModifyOrder(ctx context.Context, o *Order, price, quantity float64) error {
+	m.openMu.Lock()
+	key := executionKey(o.Exchange, o.ID)
+	if _, ok := m.open[key]; !ok || o.Status.terminal() {
+		m.openMu.Unlock()
+		return fmt.Errorf("order %s is not working", o.ID)
+	}
+	if _, ok := m.modifying[key]; ok {
+		m.openMu.Unlock()
+		return fmt.Errorf("order %s is already being modified", o.ID)
+	}
+	m.modifying[key] = false
+	working := *o
+	m.openMu.Unlock()
+
+	modified := working
+	modified.Price = price
+	modified.Quantity = quantity
+	newID, err := m.modify(ctx, &working, &modified)
+
+	m.openMu.Lock()
+	defer m.openMu.Unlock()
+	cancelled := m.modifying[key]
+	delete(m.modifying, key)
+
+	switch {
+	case errors.Is(err, exchange.ErrReplaceFailed):
+		o.Status = Cancelled
+		m.logOrder(o, fmt.Sprintf("Modification failed after cancel: %v", err))
+		delete(m.open, key)
+		delete(m.legs, key)
+		delete(m.trades, key)
+		return err
+	case cancelled && (err != nil || newID == o.ID):
+		// The order was cancelled by someone else, not replaced
+		o.Status = Cancelled
+		m.logOrder(o, "Order cancelled during modification")
+		delete(m.open, key)
+		delete(m.legs, key)
+		delete(m.trades, key)
+		if err == nil {
+			err = fmt.Errorf("order %s was cancelled during modification", o.ID)
 		}
+		return err
+	case err != nil:
+		return err
+	}
 
-		// Get exchange client
-		ex, ok := m.exchangeManager.GetExchange(o.Exchange)
-		if !ok {
-			o.Status = Failed
-			m.logOrder(o, fmt.Sprintf("Exchange not found: %s", o.Exchange))
+	o.Price = modified.Price
+	o.Quantity = modified.Quantity
+	if newID != o.ID {
+		if cancelled {
+			// The old order already reported its cancellation
+			delete(m.open, key)
+			delete(m.legs, key)
+			delete(m.trades, key)
+		}
+		o.ReplacedIDs = append(o.ReplacedIDs, o.ID)
+		o.ID = newID
+
+		newKey := executionKey(o.Exchange, newID)
+		m.open[newKey] = o
+		for _, r := range m.pending[newKey] {
+			m.applyExecution(newKey, o, r)
+		}
+		delete(m.pending, newKey)
+	}
+	m.logOrder(o, fmt.Sprintf("Modified to %v @ %v", o.Quantity, o.Price))
+	return nil
+}
+
+// modify checks a modification against the venue's trading rules and the
+// risk controller and sends it, returning the order's exchange ID afterwards
+func (m *Manager) modify(ctx context.Context, working, modified *Order) (string, error) {
+	if inst, ok := m.exchangeManager.GetInstrument(modified.Exchange, modified.Symbol); ok {
+		if err := inst.Normalize(modified); err != nil {
+			return "", fmt.Errorf("modification violates %s trading rules: %w", modified.Exchange, err)
+		}
+	}
+
+	riskApproved, err := m.riskClient.CheckOrder(modified)
+	if err != nil {
+		return "", fmt.Errorf("risk check failed: %w", err)
+	}
+	if !riskApproved {
+		return "", fmt.Errorf("modification rejected by risk controller")
+	}
+
+	return m.exchangeManager.ModifyOrder(ctx, working.Exchange, working, modified.Price, modified.Quantity)
+}
+
+// HandleExecutions applies execution reports from the exchanges' private
+// streams to orders placed through the manager until the context ends
+func (m *Manager) HandleExecutions(ctx context.Context) {
+	reports, err := m.exchangeManager.StreamExecutions(ctx)
+	if err != nil {
+		log.Printf("Failed to open execution streams: %v", err)
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
 			return
+		case r := <-reports:
+			m.handleExecution(r)
 		}
+	}
+}
 
-		// Place order
-		exchangeID, err := ex.PlaceOrder(ctx, o)
-		if err != nil {
-			o.Status = Failed
-			m.logOrder(o, fmt.Sprintf("Placement attempt %d failed: %v", i+1, err))
-			continue
+func executionKey(exchangeName, orderID string) string {
+	return exchangeName + "/" + orderID
+}
+
+// track registers a placed order and applies any reports that raced ahead
+// of the placement response
+func (m *Manager) track(o *Order) {
+	m.openMu.Lock()
+	defer m.openMu.Unlock()
+
+	key := executionKey(o.Exchange, o.ID)
+	m.open[key] = o
+	for _, r := range m.pending[key] {
+		m.applyExecution(key, o, r)
+	}
+	delete(m.pending, key)
+}
+
+func (m *Manager) handleExecution(r exchange.ExecutionReport) {
+	m.openMu.Lock()
+	defer m.openMu.Unlock()
+
+	key := executionKey(r.Exchange, r.OrderID)
+	if o, ok := m.open[key]; ok {
+		m.applyExecution(key, o, r)
+		return
+	}
+
+	// Drop reports for orders that were never placed through us
+	for k, reports := range m.pending {
+		if time.Since(reports[0].Timestamp) > pendingReportTTL {
+			delete(m.pending, k)
 		}
+	}
+	m.pending[key] = append(m.pending[key], r)
+}
 
-		o.ID = exchangeID
-		o.Status = SentToExchange
-		m.logOrder(o, "Order sent to exchange")
+// applyExecution updates an order from a report. Must be called with openMu held.
+func (m *Manager) applyExecution(key string, o *Order, r exchange.ExecutionReport) {
+	// Reported quantities are per exchange order; sum them over the legs
+	// of a replaced order
+	filled := m.legs[key]
+	if r.IsFill() {
+		if r.TradeID != "" {
+			if m.trades[key][r.TradeID] {
+				return
+			}
+			if m.trades[key] == nil {
+				m.trades[key] = make(map[string]bool)
+			}
+			m.trades[key][r.TradeID] = true
+		}
+		if r.FilledQuantity > 0 {
+			filled = math.Max(filled, r.FilledQuantity)
+		} else {
+			filled += r.LastFillQuantity
+		}
+		// Not every stream reports fees; charge the cached account rate
+		fee := r.Fee
+		if fee == 0 && r.FeeCurrency == "" {
+			rate := m.exchangeManager.Fees(o.Exchange, o.Symbol).Rate(o.Type)
+			fee = r.LastFillPrice * r.LastFillQuantity * rate
+		}
+		o.Fee += fee
+
+		trade := &db.Trade{
+			OrderID:     o.ID,
+			ExchangeID:  r.TradeID,
+			Symbol:      o.Symbol,
+			Price:       r.LastFillPrice,
+			Quantity:    r.LastFillQuantity,
+			Fee:         fee,
+			FeeCurrency: r.FeeCurrency,
+			ExecutedAt:  r.Timestamp,
+			Side:        o.Side,
+		}
+		if err := m.db.LogTrade(trade); err != nil {
+			log.Printf("Failed to log trade for order %s: %v", o.ID, err)
+		}
+	} else if r.FilledQuantity > filled {
+		filled = r.FilledQuantity
+	}
+	o.FilledQuantity += filled - m.legs[key]
+	m.legs[key] = filled
+	if r.AveragePrice > 0 {
+		o.AvgFillPrice = r.AveragePrice
+	}
+
+	// Only the current exchange order drives the status; replaced ones are
+	// forgotten once they report their cancellation
+	if key != executionKey(o.Exchange, o.ID) {
+		if r.IsFill() {
+			m.logOrder(o, fmt.Sprintf("Filled %v @ %v on replaced order", r.LastFillQuantity, r.LastFillPrice))
+		}
+		if r.Status.terminal() {
+			delete(m.open, key)
+			delete(m.legs, key)
+			delete(m.trades, key)
+		}
 		return
 	}
+	// Hold back the cancellation of an order that may be being replaced
+	if _, ok := m.modifying[key]; ok && r.Status == Cancelled {
+		m.modifying[key] = true
+		return
+	}
+
+	// Reports can arrive out of order; never move back from a final state
+	if o.Status.terminal() || (!r.Status.terminal() && r.Status <= o.Status) {
+		if r.IsFill() {
+			m.logOrder(o, fmt.Sprintf("Filled %v @ %v", r.LastFillQuantity, r.LastFillPrice))
+		}
+		return
+	}
+
+	o.Status = r.Status
+	m.logOrder(o, fmt.Sprintf("Status %d, filled %v of %v", o.Status, o.FilledQuantity, o.Quantity))
+	if o.Status.terminal() {
+		delete(m.open, key)
+		delete(m.legs, key)
+		delete(m.trades, key)
+	}
 }
 
 func (m *Manager) getMarketPrice(symbol string) (float64, error) {
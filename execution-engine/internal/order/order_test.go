@@ -0,0 +1,71 @@
+package order
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/trading-system/execution-engine/internal/exchange"
+	"github.com/trading-system/execution-engine/pkg/db"
+)
+
+// memoryStore records what the manager would write to the database
+type memoryStore struct {
+	orders []Order
+	trades []db.Trade
+}
+
+func (s *memoryStore) LogOrder(o *Order) error {
+	s.orders = append(s.orders, *o)
+	return nil
+}
+
+func (s *memoryStore) LogTrade(t *db.Trade) error {
+	s.trades = append(s.trades, *t)
+	return nil
+}
+
+func newTestManager() (*Manager, *memoryStore) {
+	store := &memoryStore{}
+	m := NewManager(exchange.NewManager(nil), nil, nil)
+	m.db = store
+	return m, store
+}
+
+func TestRepeatedFillsAppliedOnce(t *testing.T) {
+	m, store := newTestManager()
+	o := &Order{ID: "1", Exchange: "sim", Symbol: "BTC/USDT", Type: Limit, Side: Buy, Price: 100, Quantity: 1, Status: SentToExchange}
+	m.track(o)
+
+	report := func(tradeID string, qty, cumulative float64) exchange.ExecutionReport {
+		return exchange.ExecutionReport{
+			Exchange: "sim", OrderID: "1", Status: PartiallyFilled,
+			FilledQuantity: cumulative, LastFillPrice: 100, LastFillQuantity: qty,
+			TradeID: tradeID, Fee: 0.1, FeeCurrency: "USDT", Timestamp: time.Now(),
+		}
+	}
+
+	// One venue reports cumulative quantities, one only the last fill; a
+	// duplicate of either must change nothing
+	for _, r := range []exchange.ExecutionReport{
+		report("t1", 0.4, 0.4),
+		report("t1", 0.4, 0.4),
+		report("t2", 0.3, 0),
+		report("t2", 0.3, 0),
+	} {
+		m.handleExecution(r)
+	}
+
+	if len(store.trades) != 2 {
+		t.Errorf("logged %d trades, want 2", len(store.trades))
+	}
+	if math.Abs(o.Fee-0.2) > 1e-9 {
+		t.Errorf("fee = %v, want 0.2", o.Fee)
+	}
+	if math.Abs(o.FilledQuantity-0.7) > 1e-9 {
+		t.Errorf("filled = %v, want 0.7", o.FilledQuantity)
+	}
+	if o.Status != PartiallyFilled {
+		t.Errorf("status = %v, want PartiallyFilled", o.Status)
+	}
+}
@@ -0,0 +1,60 @@
+package rebalance
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// LoadConfig reads the targets and routes from the JSON file named by
+// REBALANCE_CONFIG, e.g.
+//
+//	{
+//	  "targets": [{"exchange": "binance", "asset": "USDT", "amount": 50000}],
+//	  "routes": [{"from": "kraken", "to": "binance", "asset": "USDT", "network": "TRC20", "key": "binance-usdt"}],
+//	  "tolerance": 0.2,
+//	  "dry_run": false
+//	}
+//
+// Tolerance defaults to 0.2 and transfers stay dry runs unless dry_run is
+// set to false. Without REBALANCE_CONFIG the config is empty.
+func LoadConfig() (Config, error) {
+	path := os.Getenv("REBALANCE_CONFIG")
+	if path == "" {
+		return Config{}, nil
+	}
+
+	config := Config{Tolerance: 0.2, DryRun: true}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := config.validate(); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+func (c Config) validate() error {
+	if c.Tolerance < 0 || c.Tolerance >= 1 {
+		return fmt.Errorf("tolerance %g is not in [0, 1)", c.Tolerance)
+	}
+	for i, t := range c.Targets {
+		if t.Exchange == "" || t.Asset == "" || t.Amount < 0 {
+			return fmt.Errorf("target %d: exchange, asset and a non-negative amount are required", i)
+		}
+	}
+	for i, r := range c.Routes {
+		if r.From == "" || r.To == "" || r.Asset == "" {
+			return fmt.Errorf("route %d: from, to and asset are required", i)
+		}
+		if r.From == r.To {
+			return fmt.Errorf("route %d: sends %s from %s to itself", i, r.Asset, r.From)
+		}
+	}
+	return nil
+}
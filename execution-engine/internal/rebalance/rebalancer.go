@@ -0,0 +1,450 @@
+package rebalance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/trading-system/execution-engine/internal/exchange"
+	"github.com/trading-system/execution-engine/pkg/db"
+)
+
+// Target is the inventory of an asset to hold on an exchange
+type Target struct {
+	Exchange string  `json:"exchange"`
+	Asset    string  `json:"asset"`
+	Amount   float64 `json:"amount"`
+}
+
+// Route is an approved path for moving an asset between exchanges. The
+// rebalancer never sends funds along a route that is not configured.
+type Route struct {
+	From      string  `json:"from"`
+	To        string  `json:"to"`
+	Asset     string  `json:"asset"`
+	Network   string  `json:"network"`    // empty for the asset's default network
+	Key       string  `json:"key"`        // pre-approved address name on the sending exchange, if required
+	MinAmount float64 `json:"min_amount"` // smaller transfers are not worth the withdrawal fee
+}
+
+// Config controls what the rebalancer considers out of balance
+type Config struct {
+	Targets []Target `json:"targets"`
+	Routes  []Route  `json:"routes"`
+	// Tolerance is the fraction of its target an exchange may fall below
+	// before it is topped up
+	Tolerance float64 `json:"tolerance"`
+	// DryRun logs and records proposed transfers without moving funds. A
+	// proposal repeated unchanged at the next interval is not recorded again.
+	DryRun bool `json:"dry_run"`
+}
+
+// unresolvedTimeout is how long a withdrawal whose outcome is unknown is
+// looked for among the destination's deposits before it is given up
+const unresolvedTimeout = 24 * time.Hour
+
+// maxWithdrawalFee is the largest share of a transfer a withdrawal fee is
+// assumed to take when matching deposits to withdrawals of unknown outcome
+const maxWithdrawalFee = 0.05
+
+// Proposal is a transfer that would move inventory back towards targets
+type Proposal struct {
+	Route  Route
+	Amount float64
+}
+
+func (p Proposal) String() string {
+	return fmt.Sprintf("%g %s %s -> %s", p.Amount, p.Route.Asset, p.Route.From, p.Route.To)
+}
+
+// transferStore is the part of the database the rebalancer records
+// transfers in
+type transferStore interface {
+	LogTransfer(t *db.Transfer) error
+	UpdateTransfer(t *db.Transfer) error
+	GetTransfersByStatus(ctx context.Context, statuses ...int) ([]*db.Transfer, error)
+}
+
+// Rebalancer moves funds between exchanges to keep per-venue inventory at
+// its targets
+type Rebalancer struct {
+	exchangeManager *exchange.Manager
+	db              transferStore
+	config          Config
+	inflight        map[string]*db.Transfer // transfer ID -> transfer
+	proposed        map[Route]float64       // dry-run proposals of the last interval
+	mu              sync.Mutex
+}
+
+// NewRebalancer creates a new rebalancer
+func NewRebalancer(em *exchange.Manager, database *db.TimescaleDB, config Config) *Rebalancer {
+	return &Rebalancer{
+		exchangeManager: em,
+		db:              database,
+		config:          config,
+		inflight:        make(map[string]*db.Transfer),
+	}
+}
+
+// Run tracks in-flight transfers and rebalances at regular intervals.
+// Transfers left in flight by a previous run are tracked as well.
+func (r *Rebalancer) Run(ctx context.Context, interval time.Duration) {
+	if err := r.Load(ctx); err != nil {
+		log.Printf("Error loading in-flight transfers: %v", err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.Track(ctx)
+
+			proposals, err := r.Propose(ctx)
+			if err != nil {
+				log.Printf("Rebalancing failed: %v", err)
+				continue
+			}
+			if r.config.DryRun {
+				proposals = r.unrecorded(proposals)
+			}
+			for _, p := range proposals {
+				if _, err := r.Execute(ctx, p); err != nil {
+					log.Printf("Error executing transfer %s: %v", p, err)
+				}
+			}
+		}
+	}
+}
+
+// Load resumes tracking of the transfers recorded as in flight
+func (r *Rebalancer) Load(ctx context.Context) error {
+	transfers, err := r.db.GetTransfersByStatus(ctx, int(exchange.TransferPending), int(exchange.TransferProcessing))
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range transfers {
+		r.inflight[t.ID] = t
+	}
+	if len(transfers) > 0 {
+		log.Printf("Resumed tracking %d in-flight transfers", len(transfers))
+	}
+	return nil
+}
+
+// Propose compares current balances with the targets and returns the
+// transfers needed to top up exchanges that have fallen below tolerance.
+// Assets with a transfer still in flight are skipped, since the funds are
+// missing from both sides until they arrive.
+func (r *Rebalancer) Propose(ctx context.Context) ([]Proposal, error) {
+	portfolio := r.exchangeManager.Portfolio(ctx)
+
+	type holding struct{ total, free float64 }
+	holdings := make(map[string]holding)
+	for _, b := range portfolio.Balances {
+		holdings[b.Exchange+"/"+b.Asset] = holding{b.Total, b.Free}
+	}
+
+	busy := r.busyAssets()
+
+	var proposals []Proposal
+	for asset, targets := range r.targetsByAsset() {
+		if busy[asset] {
+			continue
+		}
+
+		var deficits, surpluses []Target
+		for _, t := range targets {
+			if _, failed := portfolio.Errors[t.Exchange]; failed {
+				continue
+			}
+			h := holdings[t.Exchange+"/"+asset]
+			switch {
+			case h.total < t.Amount*(1-r.config.Tolerance):
+				deficits = append(deficits, Target{t.Exchange, asset, t.Amount - h.total})
+			case h.total > t.Amount:
+				// Only funds that are free to withdraw can be moved
+				surplus := min(h.total-t.Amount, h.free)
+				if surplus > 0 {
+					surpluses = append(surpluses, Target{t.Exchange, asset, surplus})
+				}
+			}
+		}
+
+		// Fill the largest shortfalls from the largest surpluses first
+		sort.Slice(deficits, func(i, j int) bool { return deficits[i].Amount > deficits[j].Amount })
+		sort.Slice(surpluses, func(i, j int) bool { return surpluses[i].Amount > surpluses[j].Amount })
+		for _, d := range deficits {
+			for i := range surpluses {
+				s := &surpluses[i]
+				route, ok := r.route(s.Exchange, d.Exchange, asset)
+				if !ok || s.Amount <= 0 {
+					continue
+				}
+				amount := min(d.Amount, s.Amount)
+				if amount < route.MinAmount {
+					continue
+				}
+				proposals = append(proposals, Proposal{Route: route, Amount: amount})
+				s.Amount -= amount
+				d.Amount -= amount
+				if d.Amount <= 0 {
+					break
+				}
+			}
+		}
+	}
+	return proposals, nil
+}
+
+// Execute withdraws the proposed amount to the destination's deposit
+// address and records the transfer. In dry-run mode the transfer is only
+// logged and recorded.
+func (r *Rebalancer) Execute(ctx context.Context, p Proposal) (*db.Transfer, error) {
+	now := time.Now()
+	t := &db.Transfer{
+		ID:           fmt.Sprintf("rb-%d", now.UnixNano()),
+		FromExchange: p.Route.From,
+		ToExchange:   p.Route.To,
+		Asset:        p.Route.Asset,
+		Network:      p.Route.Network,
+		Amount:       p.Amount,
+		Status:       int(exchange.TransferPending),
+		DryRun:       r.config.DryRun,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	if r.config.DryRun {
+		log.Printf("Dry run: would transfer %s", p)
+		return t, r.db.LogTransfer(t)
+	}
+
+	addr, err := r.exchangeManager.GetDepositAddress(ctx, p.Route.To, p.Route.Asset, p.Route.Network)
+	if err != nil {
+		return nil, fmt.Errorf("error getting deposit address: %w", err)
+	}
+	t.Address = addr.Address
+
+	id, err := r.exchangeManager.Withdraw(ctx, p.Route.From, exchange.Withdrawal{
+		Asset:   p.Route.Asset,
+		Network: p.Route.Network,
+		Address: addr.Address,
+		Tag:     addr.Tag,
+		Amount:  p.Amount,
+		Key:     p.Route.Key,
+	})
+	if err != nil {
+		if rejected(err) {
+			t.Status = int(exchange.TransferFailed)
+		} else {
+			// The venue may have accepted the withdrawal anyway, so it stays
+			// in flight until it shows up among the destination's deposits
+			log.Printf("Transfer %s outcome unknown, watching deposits on %s: %v", t.ID, p.Route.To, err)
+			r.mu.Lock()
+			r.inflight[t.ID] = t
+			r.mu.Unlock()
+		}
+		if logErr := r.db.LogTransfer(t); logErr != nil {
+			log.Printf("Error logging transfer %s: %v", t.ID, logErr)
+		}
+		return t, err
+	}
+	t.WithdrawalID = id
+
+	r.mu.Lock()
+	r.inflight[t.ID] = t
+	r.mu.Unlock()
+
+	log.Printf("Started transfer %s: %s", t.ID, p)
+	return t, r.db.LogTransfer(t)
+}
+
+// rejected reports whether a withdrawal error means the venue definitely
+// did not send the funds
+func rejected(err error) bool {
+	class, _ := exchange.Classify(err)
+	return class == exchange.RetryNever || errors.Is(err, exchange.ErrInvalidTransfer)
+}
+
+// Track updates in-flight transfers from the sending exchange's withdrawal
+// history. A transfer completes once the destination has credited the
+// deposit and it has been moved to where the destination trades from.
+func (r *Rebalancer) Track(ctx context.Context) {
+	r.mu.Lock()
+	transfers := make([]*db.Transfer, 0, len(r.inflight))
+	for _, t := range r.inflight {
+		transfers = append(transfers, t)
+	}
+	r.mu.Unlock()
+
+	for _, t := range transfers {
+		var status exchange.TransferStatus
+		var txID string
+		var fee, credited float64
+		if t.WithdrawalID == "" {
+			var ok bool
+			if status, txID, credited, ok = r.unresolved(ctx, t, transfers); !ok {
+				continue
+			}
+			fee = t.Fee
+		} else {
+			w, err := r.exchangeManager.GetWithdrawal(ctx, t.FromExchange, t.Asset, t.WithdrawalID)
+			if err != nil {
+				log.Printf("Error getting status for transfer %s: %v", t.ID, err)
+				continue
+			}
+
+			status, txID, fee = w.Status, w.TxID, w.Fee
+			if status == exchange.TransferCompleted {
+				var ok bool
+				if credited, ok = r.deposited(ctx, t, w.TxID); !ok {
+					status = exchange.TransferProcessing
+				}
+			}
+		}
+		if int(status) == t.Status && txID == t.TxID {
+			continue
+		}
+		if status == exchange.TransferCompleted {
+			// Retried on the next pass until the funds can be traded
+			if err := r.exchangeManager.SweepDeposit(ctx, t.ToExchange, t.Asset, credited); err != nil {
+				log.Printf("Error moving transfer %s deposit on %s: %v", t.ID, t.ToExchange, err)
+				continue
+			}
+		}
+
+		t.Status = int(status)
+		t.TxID = txID
+		t.Fee = fee
+		t.UpdatedAt = time.Now()
+		if err := r.db.UpdateTransfer(t); err != nil {
+			log.Printf("Error updating transfer %s: %v", t.ID, err)
+		}
+
+		if status.Done() {
+			log.Printf("Transfer %s %s", t.ID, status)
+			r.mu.Lock()
+			delete(r.inflight, t.ID)
+			r.mu.Unlock()
+		}
+	}
+}
+
+// deposited returns the amount the destination credited for the transfer,
+// and false while it has not been credited
+func (r *Rebalancer) deposited(ctx context.Context, t *db.Transfer, txID string) (float64, bool) {
+	if txID == "" {
+		return 0, false
+	}
+	deposits, err := r.exchangeManager.GetDeposits(ctx, t.ToExchange, t.Asset, t.CreatedAt)
+	if err != nil {
+		log.Printf("Error getting deposits for transfer %s: %v", t.ID, err)
+		return 0, false
+	}
+	for _, d := range deposits {
+		if d.TxID == txID {
+			return d.Amount, d.Status == exchange.TransferCompleted
+		}
+	}
+	return 0, false
+}
+
+// unresolved looks for a withdrawal whose outcome is unknown among the
+// destination's deposits, matching on address and on an amount short of the
+// transfer by at most the withdrawal fee. Deposits already claimed by other
+// transfers are skipped. A transfer nothing arrives for is failed once
+// unresolvedTimeout has passed. The matched deposit's amount is returned
+// along with its status.
+func (r *Rebalancer) unresolved(ctx context.Context, t *db.Transfer, tracked []*db.Transfer) (exchange.TransferStatus, string, float64, bool) {
+	deposits, err := r.exchangeManager.GetDeposits(ctx, t.ToExchange, t.Asset, t.CreatedAt)
+	if err != nil {
+		log.Printf("Error getting deposits for transfer %s: %v", t.ID, err)
+		return 0, "", 0, false
+	}
+	if t.TxID != "" {
+		// Matched earlier: follow the deposit until it is credited
+		for _, d := range deposits {
+			if d.TxID == t.TxID {
+				return d.Status, d.TxID, d.Amount, true
+			}
+		}
+		return 0, "", 0, false
+	}
+
+	claimed := make(map[string]bool)
+	for _, other := range tracked {
+		if other.TxID != "" {
+			claimed[other.TxID] = true
+		}
+	}
+	for _, d := range deposits {
+		if d.TxID == "" || claimed[d.TxID] || (d.Address != "" && d.Address != t.Address) {
+			continue
+		}
+		if d.Amount <= t.Amount && d.Amount >= t.Amount*(1-maxWithdrawalFee) {
+			log.Printf("Transfer %s matched deposit %s on %s", t.ID, d.TxID, t.ToExchange)
+			return d.Status, d.TxID, d.Amount, true
+		}
+	}
+
+	if time.Since(t.CreatedAt) > unresolvedTimeout {
+		log.Printf("Transfer %s never arrived on %s, check %s withdrawals manually", t.ID, t.ToExchange, t.FromExchange)
+		return exchange.TransferFailed, "", 0, true
+	}
+	return 0, "", 0, false
+}
+
+// unrecorded drops proposals identical to those of the previous interval,
+// so that a dry run records an imbalance once rather than at every interval
+func (r *Rebalancer) unrecorded(proposals []Proposal) []Proposal {
+	last := r.proposed
+	r.proposed = make(map[Route]float64, len(proposals))
+
+	var fresh []Proposal
+	for _, p := range proposals {
+		r.proposed[p.Route] = p.Amount
+		if amount, ok := last[p.Route]; !ok || amount != p.Amount {
+			fresh = append(fresh, p)
+		}
+	}
+	return fresh
+}
+
+func (r *Rebalancer) busyAssets() map[string]bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	busy := make(map[string]bool)
+	for _, t := range r.inflight {
+		busy[t.Asset] = true
+	}
+	return busy
+}
+
+func (r *Rebalancer) targetsByAsset() map[string][]Target {
+	targets := make(map[string][]Target)
+	for _, t := range r.config.Targets {
+		targets[t.Asset] = append(targets[t.Asset], t)
+	}
+	return targets
+}
+
+func (r *Rebalancer) route(from, to, asset string) (Route, bool) {
+	for _, route := range r.config.Routes {
+		if route.From == from && route.To == to && route.Asset == asset {
+			return route, true
+		}
+	}
+	return Route{}, false
+}
@@ -0,0 +1,202 @@
+package rebalance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/trading-system/execution-engine/internal/exchange"
+	"github.com/trading-system/execution-engine/pkg/db"
+)
+
+// memoryStore keeps the transfers the rebalancer records
+type memoryStore struct {
+	logged  []db.Transfer
+	updated []db.Transfer
+	pending []*db.Transfer
+}
+
+func (s *memoryStore) LogTransfer(t *db.Transfer) error {
+	s.logged = append(s.logged, *t)
+	return nil
+}
+
+func (s *memoryStore) UpdateTransfer(t *db.Transfer) error {
+	s.updated = append(s.updated, *t)
+	return nil
+}
+
+func (s *memoryStore) GetTransfersByStatus(ctx context.Context, statuses ...int) ([]*db.Transfer, error) {
+	return s.pending, nil
+}
+
+// stubVenue is a simulated exchange that can also move funds
+type stubVenue struct {
+	*exchange.SimExchange
+	deposits []exchange.Transfer
+	swept    []float64
+}
+
+func (v *stubVenue) GetDepositAddress(ctx context.Context, asset, network string) (*exchange.DepositAddress, error) {
+	return &exchange.DepositAddress{Asset: asset, Network: network, Address: "addr"}, nil
+}
+
+func (v *stubVenue) Withdraw(ctx context.Context, w exchange.Withdrawal) (string, error) {
+	return "w1", nil
+}
+
+func (v *stubVenue) GetWithdrawal(ctx context.Context, asset, id string) (*exchange.Transfer, error) {
+	return nil, exchange.ErrTransferNotFound
+}
+
+func (v *stubVenue) GetDeposits(ctx context.Context, asset string, since time.Time) ([]exchange.Transfer, error) {
+	return v.deposits, nil
+}
+
+func (v *stubVenue) SweepDeposit(ctx context.Context, asset string, amount float64) error {
+	v.swept = append(v.swept, amount)
+	return nil
+}
+
+func newStubVenue(t *testing.T, balances map[string]float64) *stubVenue {
+	t.Helper()
+	sim := exchange.NewSimExchange(exchange.SimConfig{Balances: balances})
+	if err := sim.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	return &stubVenue{SimExchange: sim}
+}
+
+func newTestRebalancer(config Config, venues map[string]*stubVenue) (*Rebalancer, *memoryStore) {
+	exchanges := make(map[string]exchange.Interface, len(venues))
+	for name, v := range venues {
+		exchanges[name] = v
+	}
+	store := &memoryStore{}
+	r := NewRebalancer(exchange.NewManager(exchanges), nil, config)
+	r.db = store
+	return r, store
+}
+
+func TestPropose(t *testing.T) {
+	venues := map[string]*stubVenue{
+		"binance":  newStubVenue(t, map[string]float64{"USDT": 500, "BTC": 0.95}),
+		"kraken":   newStubVenue(t, map[string]float64{"USDT": 2000, "BTC": 2}),
+		"coinbase": newStubVenue(t, map[string]float64{"USDT": 0}),
+	}
+	r, _ := newTestRebalancer(Config{
+		Targets: []Target{
+			{"binance", "USDT", 1000},
+			{"kraken", "USDT", 1000},
+			{"coinbase", "USDT", 1000},
+			{"binance", "BTC", 1},
+			{"kraken", "BTC", 1},
+		},
+		Routes: []Route{
+			{From: "kraken", To: "binance", Asset: "USDT", MinAmount: 10},
+			{From: "kraken", To: "binance", Asset: "BTC"},
+		},
+		Tolerance: 0.1,
+	}, venues)
+
+	proposals, err := r.Propose(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Coinbase has no route and BTC on Binance is within tolerance
+	if len(proposals) != 1 {
+		t.Fatalf("proposals = %v, want one", proposals)
+	}
+	if p := proposals[0]; p.Route.From != "kraken" || p.Route.To != "binance" || p.Route.Asset != "USDT" || p.Amount != 500 {
+		t.Errorf("proposal = %v, want 500 USDT kraken -> binance", p)
+	}
+
+	// Nothing more is sent while a transfer of the asset is in flight
+	r.inflight["rb-1"] = &db.Transfer{ID: "rb-1", Asset: "USDT"}
+	if proposals, _ := r.Propose(context.Background()); len(proposals) != 0 {
+		t.Errorf("proposals = %v with a transfer in flight", proposals)
+	}
+}
+
+func TestTrackUnresolvedDeposits(t *testing.T) {
+	binance := newStubVenue(t, nil)
+	r, store := newTestRebalancer(Config{}, map[string]*stubVenue{
+		"kraken":  newStubVenue(t, nil),
+		"binance": binance,
+	})
+	created := time.Now().Add(-time.Hour)
+	matched := &db.Transfer{ID: "rb-1", FromExchange: "kraken", ToExchange: "binance", Asset: "USDT", Address: "addr", Amount: 100, TxID: "tx-a", Status: int(exchange.TransferProcessing), CreatedAt: created}
+	unmatched := &db.Transfer{ID: "rb-2", FromExchange: "kraken", ToExchange: "binance", Asset: "USDT", Address: "addr", Amount: 100, CreatedAt: created}
+	r.inflight[matched.ID] = matched
+	r.inflight[unmatched.ID] = unmatched
+
+	binance.deposits = []exchange.Transfer{
+		{TxID: "tx-a", Address: "addr", Amount: 99, Status: exchange.TransferCompleted},
+		{TxID: "tx-b", Address: "other", Amount: 99, Status: exchange.TransferCompleted},
+		{TxID: "tx-c", Address: "addr", Amount: 90, Status: exchange.TransferCompleted},
+		{TxID: "tx-d", Address: "addr", Amount: 98.5, Status: exchange.TransferProcessing},
+	}
+	r.Track(context.Background())
+
+	// The deposit matched earlier completes and is moved to the trading wallet
+	if matched.Status != int(exchange.TransferCompleted) || r.inflight[matched.ID] != nil {
+		t.Errorf("matched transfer %+v still in flight", matched)
+	}
+	if len(binance.swept) != 1 || binance.swept[0] != 99 {
+		t.Errorf("swept %v, want 99", binance.swept)
+	}
+
+	// Claimed deposits, other addresses and amounts short by more than the
+	// fee are skipped
+	if unmatched.TxID != "tx-d" || unmatched.Status != int(exchange.TransferProcessing) || r.inflight[unmatched.ID] == nil {
+		t.Errorf("unmatched transfer = %+v, want processing on tx-d", unmatched)
+	}
+	if len(store.updated) != 2 {
+		t.Errorf("recorded %d updates, want 2", len(store.updated))
+	}
+
+	// Nothing arriving within the timeout fails the transfer
+	lost := &db.Transfer{ID: "rb-3", FromExchange: "kraken", ToExchange: "binance", Asset: "BTC", Address: "addr", Amount: 1, CreatedAt: time.Now().Add(-2 * unresolvedTimeout)}
+	r.inflight[lost.ID] = lost
+	r.Track(context.Background())
+	if lost.Status != int(exchange.TransferFailed) || r.inflight[lost.ID] != nil {
+		t.Errorf("lost transfer = %+v, want failed", lost)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	r, store := newTestRebalancer(Config{}, nil)
+	store.pending = []*db.Transfer{
+		{ID: "rb-1", Asset: "USDT", Status: int(exchange.TransferPending)},
+		{ID: "rb-2", Asset: "BTC", Status: int(exchange.TransferProcessing)},
+	}
+	if err := r.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	busy := r.busyAssets()
+	if len(r.inflight) != 2 || !busy["USDT"] || !busy["BTC"] {
+		t.Errorf("inflight = %v, want both transfers resumed", r.inflight)
+	}
+}
+
+func TestDryRunRecordsOnce(t *testing.T) {
+	r, _ := newTestRebalancer(Config{DryRun: true}, nil)
+	route := Route{From: "kraken", To: "binance", Asset: "USDT"}
+	proposal := []Proposal{{Route: route, Amount: 500}}
+
+	if got := r.unrecorded(proposal); len(got) != 1 {
+		t.Fatalf("first interval = %v, want the proposal", got)
+	}
+	if got := r.unrecorded(proposal); len(got) != 0 {
+		t.Errorf("repeated proposal = %v, want none", got)
+	}
+	if got := r.unrecorded([]Proposal{{Route: route, Amount: 600}}); len(got) != 1 {
+		t.Errorf("changed proposal = %v, want it recorded", got)
+	}
+
+	// An imbalance that went away and came back is recorded again
+	r.unrecorded(nil)
+	if got := r.unrecorded([]Proposal{{Route: route, Amount: 600}}); len(got) != 1 {
+		t.Errorf("recurring proposal = %v, want it recorded", got)
+	}
+}
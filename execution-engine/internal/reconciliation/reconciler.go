@@ -30,6 +30,7 @@ func (r *Reconciler) Run(ctx context.Context, interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	changes := r.exchangeManager.Changes(ctx)
 	for {
 		select {
 		case <-ctx.Done():
@@ -38,6 +39,14 @@ func (r *Reconciler) Run(ctx context.Context, interval time.Duration) {
 			if err := r.Reconcile(ctx); err != nil {
 				log.Printf("Reconciliation failed: %v", err)
 			}
+		case ev, ok := <-changes:
+			// Catch up on orders as soon as a venue comes back
+			if !ok || ev.Type != exchange.ExchangeAdded {
+				continue
+			}
+			if err := r.Reconcile(ctx); err != nil {
+				log.Printf("Reconciliation failed: %v", err)
+			}
 		}
 	}
 }
@@ -52,16 +61,20 @@ func (r *Reconciler) Reconcile(ctx context.Context) error {
 		return fmt.Errorf("error fetching orders: %w", err)
 	}
 
+	deferred := make(map[string]int)
 	for _, o := range orders {
-		// Get exchange client
-		ex, ok := r.exchangeManager.GetExchange(o.Exchange)
-		if !ok {
-			log.Printf("Exchange %s not found for order %s", o.Exchange, o.ID)
+		// Venues taken out for maintenance are reconciled once they return
+		if _, ok := r.exchangeManager.GetExchange(o.Exchange); !ok {
+			deferred[o.Exchange]++
 			continue
 		}
 
+		// Venues that look orders up by symbol may not know ones placed
+		// before a restart or already forgotten as terminal
+		r.exchangeManager.TrackOrder(o.Exchange, o.ID, o.Symbol)
+
 		// Get order status from exchange
-		status, err := ex.GetOrderStatus(o.ID)
+		status, err := r.exchangeManager.GetOrderStatus(o.Exchange, o.ID)
 		if err != nil {
 			log.Printf("Error getting status for order %s: %v", o.ID, err)
 			continue
@@ -88,6 +101,9 @@ func (r *Reconciler) Reconcile(ctx context.Context) error {
 		}
 	}
 
+	for name, n := range deferred {
+		log.Printf("Exchange %s not registered, deferring %d orders", name, n)
+	}
 	log.Println("Reconciliation completed")
 	return nil
 }
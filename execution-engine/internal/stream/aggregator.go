@@ -14,6 +14,8 @@ type Aggregator struct {
 	exchangeManager *exchange.Manager
 	streams         map[string]map[string]chan exchange.TradeEvent
 	aggregated      map[string]chan exchange.TradeEvent
+	states          map[string]map[string]exchange.ConnectionState // symbol -> exchange -> state
+	ctx             context.Context
 	mu              sync.RWMutex
 }
 
@@ -23,17 +25,27 @@ func NewAggregator(em *exchange.Manager) *Aggregator {
 		exchangeManager: em,
 		streams:         make(map[string]map[string]chan exchange.TradeEvent),
 		aggregated:      make(map[string]chan exchange.TradeEvent),
+		states:          make(map[string]map[string]exchange.ConnectionState),
+		ctx:             context.Background(),
 	}
 }
 
 // Start initializes the aggregator
 func (a *Aggregator) Start(ctx context.Context) {
 	log.Println("Starting stream aggregator")
+	a.mu.Lock()
+	a.ctx = ctx
+	a.mu.Unlock()
 	go a.monitorStreams(ctx)
+	go a.watchConnections(ctx)
+	go a.watchRegistry(ctx)
 }
 
-// GetStream returns the aggregated trade stream for a symbol
+// GetStream returns the aggregated trade stream for a symbol. Symbols are
+// canonicalized, so BTCUSDT and BTC/USDT share one stream across venues.
 func (a *Aggregator) GetStream(symbol string) (<-chan exchange.TradeEvent, error) {
+	symbol = exchange.NormalizeSymbol(symbol)
+
 	a.mu.RLock()
 	ch, exists := a.aggregated[symbol]
 	a.mu.RUnlock()
@@ -42,6 +54,9 @@ func (a *Aggregator) GetStream(symbol string) (<-chan exchange.TradeEvent, error
 		return ch, nil
 	}
 
+	a.mu.RLock()
+	ctx := a.ctx
+	a.mu.RUnlock()
 	return a.createStream(ctx, symbol)
 }
 
@@ -60,6 +75,7 @@ func (a *Aggregator) createStream(ctx context.Context, symbol string) (<-chan ex
 
 	// Initialize exchange streams for this symbol
 	a.streams[symbol] = make(map[string]chan exchange.TradeEvent)
+	a.states[symbol] = make(map[string]exchange.ConnectionState)
 
 	// Get all exchanges
 	exchanges := a.exchangeManager.GetAllExchanges()
@@ -71,6 +87,7 @@ func (a *Aggregator) createStream(ctx context.Context, symbol string) (<-chan ex
 		}
 
 		a.streams[symbol][name] = exCh
+		a.states[symbol][name] = exchange.Connected
 		go a.fanIn(name, symbol, exCh, aggCh)
 	}
 
@@ -87,6 +104,91 @@ func (a *Aggregator) fanIn(exchangeName, symbol string, in <-chan exchange.Trade
 			log.Printf("Aggregator channel full for %s, dropping trade", symbol)
 		}
 	}
+
+	// Exchanges reconnect on their own; a closed channel means the stream
+	// gave up, so mark it for the health check to reopen
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.streams[symbol][exchangeName] == in {
+		a.streams[symbol][exchangeName] = nil
+		a.states[symbol][exchangeName] = exchange.Disconnected
+	}
+}
+
+// watchConnections tracks the state exchanges report for their trade streams
+func (a *Aggregator) watchConnections(ctx context.Context) {
+	for ev := range a.exchangeManager.ConnectionEvents(ctx) {
+		if ev.Stream != "trades" {
+			continue
+		}
+		symbol := exchange.NormalizeSymbol(ev.Symbol)
+
+		a.mu.Lock()
+		if states, ok := a.states[symbol]; ok {
+			states[ev.Exchange] = ev.State
+		}
+		a.mu.Unlock()
+
+		if ev.State == exchange.Reconnecting {
+			log.Printf("%s stream for %s reconnecting (attempt %d): %v", ev.Exchange, symbol, ev.Attempt, ev.Err)
+		}
+	}
+}
+
+// watchRegistry adds newly registered exchanges to every aggregated symbol
+// and forgets exchanges that are removed
+func (a *Aggregator) watchRegistry(ctx context.Context) {
+	for ev := range a.exchangeManager.Changes(ctx) {
+		switch ev.Type {
+		case exchange.ExchangeAdded:
+			a.addExchange(ctx, ev.Exchange)
+		case exchange.ExchangeRemoved:
+			a.mu.Lock()
+			for symbol := range a.streams {
+				delete(a.streams[symbol], ev.Exchange)
+				delete(a.states[symbol], ev.Exchange)
+			}
+			a.mu.Unlock()
+			log.Printf("Stopped aggregating %s", ev.Exchange)
+		}
+	}
+}
+
+func (a *Aggregator) addExchange(ctx context.Context, exchangeName string) {
+	ex, ok := a.exchangeManager.GetExchange(exchangeName)
+	if !ok {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for symbol, aggCh := range a.aggregated {
+		if _, exists := a.streams[symbol][exchangeName]; exists {
+			continue
+		}
+		exCh, err := ex.StreamTrades(ctx, symbol)
+		if err != nil {
+			log.Printf("Error creating %s stream for %s: %v", exchangeName, symbol, err)
+			continue
+		}
+		a.streams[symbol][exchangeName] = exCh
+		a.states[symbol][exchangeName] = exchange.Connected
+		go a.fanIn(exchangeName, symbol, exCh, aggCh)
+	}
+}
+
+// StreamStates returns the connection state of each exchange feeding a symbol
+func (a *Aggregator) StreamStates(symbol string) map[string]exchange.ConnectionState {
+	symbol = exchange.NormalizeSymbol(symbol)
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	states := make(map[string]exchange.ConnectionState, len(a.states[symbol]))
+	for name, state := range a.states[symbol] {
+		states[name] = state
+	}
+	return states
 }
 
 func (a *Aggregator) monitorStreams(ctx context.Context) {
@@ -109,8 +211,8 @@ func (a *Aggregator) checkStreamHealth(ctx context.Context) {
 
 	for symbol, exchanges := range a.streams {
 		for exchangeName, ch := range exchanges {
-			// Check if channel is closed
-			if ch == nil {
+			// fanIn clears the channel once the exchange closes it
+			if ch == nil && ctx.Err() == nil {
 				log.Printf("Reconnecting %s stream for %s", exchangeName, symbol)
 				go a.reconnectStream(ctx, exchangeName, symbol)
 			}
@@ -129,9 +231,9 @@ func (a *Aggregator) reconnectStream(ctx context.Context, exchangeName, symbol s
 		return
 	}
 
-	// Close old channel if exists
-	if oldCh, exists := a.streams[symbol][exchangeName]; exists {
-		close(oldCh)
+	// Another health check may have reopened it already
+	if ch := a.streams[symbol][exchangeName]; ch != nil {
+		return
 	}
 
 	// Create new stream
@@ -142,5 +244,6 @@ func (a *Aggregator) reconnectStream(ctx context.Context, exchangeName, symbol s
 	}
 
 	a.streams[symbol][exchangeName] = newCh
+	a.states[symbol][exchangeName] = exchange.Connected
 	go a.fanIn(exchangeName, symbol, newCh, a.aggregated[symbol])
 }
\ No newline at end of file
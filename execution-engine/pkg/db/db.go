@@ -80,6 +80,130 @@ func (db *TimescaleDB) LogTrade(t *Trade) error {
 	return err
 }
 
+// LogTransfer logs a fund transfer between exchanges to the database
+func (db *TimescaleDB) LogTransfer(t *Transfer) error {
+	query := `
+		INSERT INTO transfers (
+			id, withdrawal_id, from_exchange, to_exchange, asset, network,
+			address, amount, fee, tx_id, status, dry_run, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+		)
+	`
+
+	_, err := db.pool.Exec(context.Background(), query,
+		t.ID, t.WithdrawalID, t.FromExchange, t.ToExchange, t.Asset, t.Network,
+		t.Address, t.Amount, t.Fee, t.TxID, t.Status, t.DryRun, t.CreatedAt, t.UpdatedAt,
+	)
+
+	return err
+}
+
+// UpdateTransfer records the latest state of a logged transfer
+func (db *TimescaleDB) UpdateTransfer(t *Transfer) error {
+	query := `
+		UPDATE transfers
+		SET withdrawal_id = $2, address = $3, fee = $4, tx_id = $5, status = $6,
+			updated_at = $7
+		WHERE id = $1 AND created_at = $8
+	`
+
+	_, err := db.pool.Exec(context.Background(), query,
+		t.ID, t.WithdrawalID, t.Address, t.Fee, t.TxID, t.Status, t.UpdatedAt, t.CreatedAt,
+	)
+
+	return err
+}
+
+// GetTransfersByStatus returns the transfers other than dry runs in any of
+// the given statuses, oldest first
+func (db *TimescaleDB) GetTransfersByStatus(ctx context.Context, statuses ...int) ([]*Transfer, error) {
+	query := `
+		SELECT id, withdrawal_id, from_exchange, to_exchange, asset, network,
+			address, amount, fee, tx_id, status, dry_run, created_at, updated_at
+		FROM transfers
+		WHERE NOT dry_run AND status = ANY($1)
+		ORDER BY created_at
+	`
+
+	rows, err := db.pool.Query(ctx, query, statuses)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transfers []*Transfer
+	for rows.Next() {
+		var t Transfer
+		var withdrawalID, network, address, txID *string
+		var fee *float64
+		if err := rows.Scan(
+			&t.ID, &withdrawalID, &t.FromExchange, &t.ToExchange, &t.Asset, &network,
+			&address, &t.Amount, &fee, &txID, &t.Status, &t.DryRun, &t.CreatedAt, &t.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		t.WithdrawalID = deref(withdrawalID)
+		t.Network = deref(network)
+		t.Address = deref(address)
+		t.TxID = deref(txID)
+		if fee != nil {
+			t.Fee = *fee
+		}
+		transfers = append(transfers, &t)
+	}
+	return transfers, rows.Err()
+}
+
+// deref returns the value of a nullable text column
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// InsertKlines bulk-loads historical candles, skipping ones already stored
+func (db *TimescaleDB) InsertKlines(ctx context.Context, klines []Kline) error {
+	query := `
+		INSERT INTO klines (
+			exchange, symbol, interval_seconds, open_time, open, high, low, close,
+			volume, trades
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+		) ON CONFLICT DO NOTHING
+	`
+
+	batch := &pgx.Batch{}
+	for _, k := range klines {
+		batch.Queue(query,
+			k.Exchange, k.Symbol, int64(k.Interval.Seconds()), k.OpenTime, k.Open,
+			k.High, k.Low, k.Close, k.Volume, k.Trades,
+		)
+	}
+	return db.pool.SendBatch(ctx, batch).Close()
+}
+
+// InsertMarketTrades bulk-loads historical public trades, skipping ones
+// already stored
+func (db *TimescaleDB) InsertMarketTrades(ctx context.Context, trades []MarketTrade) error {
+	query := `
+		INSERT INTO market_trades (
+			exchange, symbol, trade_id, price, quantity, side, executed_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7
+		) ON CONFLICT DO NOTHING
+	`
+
+	batch := &pgx.Batch{}
+	for _, t := range trades {
+		batch.Queue(query,
+			t.Exchange, t.Symbol, t.TradeID, t.Price, t.Quantity, int(t.Side), t.ExecutedAt,
+		)
+	}
+	return db.pool.SendBatch(ctx, batch).Close()
+}
+
 // CreateSchema creates the necessary tables if they don't exist
 func (db *TimescaleDB) CreateSchema(ctx context.Context) error {
 	queries := []string{
@@ -114,6 +238,55 @@ func (db *TimescaleDB) CreateSchema(ctx context.Context) error {
 		
 		`SELECT create_hypertable('trades', 'executed_at', if_not_exists => TRUE)`,
 		
+		`CREATE TABLE IF NOT EXISTS transfers (
+			id TEXT NOT NULL,
+			withdrawal_id TEXT,
+			from_exchange TEXT NOT NULL,
+			to_exchange TEXT NOT NULL,
+			asset TEXT NOT NULL,
+			network TEXT,
+			address TEXT,
+			amount DOUBLE PRECISION NOT NULL,
+			fee DOUBLE PRECISION,
+			tx_id TEXT,
+			status SMALLINT NOT NULL,
+			dry_run BOOLEAN NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL,
+			PRIMARY KEY (id, created_at)
+		)`,
+
+		`SELECT create_hypertable('transfers', 'created_at', if_not_exists => TRUE)`,
+
+		`CREATE TABLE IF NOT EXISTS klines (
+			exchange TEXT NOT NULL,
+			symbol TEXT NOT NULL,
+			interval_seconds INTEGER NOT NULL,
+			open_time TIMESTAMPTZ NOT NULL,
+			open DOUBLE PRECISION NOT NULL,
+			high DOUBLE PRECISION NOT NULL,
+			low DOUBLE PRECISION NOT NULL,
+			close DOUBLE PRECISION NOT NULL,
+			volume DOUBLE PRECISION NOT NULL,
+			trades BIGINT,
+			PRIMARY KEY (exchange, symbol, interval_seconds, open_time)
+		)`,
+
+		`SELECT create_hypertable('klines', 'open_time', if_not_exists => TRUE)`,
+
+		`CREATE TABLE IF NOT EXISTS market_trades (
+			exchange TEXT NOT NULL,
+			symbol TEXT NOT NULL,
+			trade_id TEXT NOT NULL,
+			price DOUBLE PRECISION NOT NULL,
+			quantity DOUBLE PRECISION NOT NULL,
+			side SMALLINT NOT NULL,
+			executed_at TIMESTAMPTZ NOT NULL,
+			PRIMARY KEY (exchange, symbol, trade_id, executed_at)
+		)`,
+
+		`SELECT create_hypertable('market_trades', 'executed_at', if_not_exists => TRUE)`,
+
 		`CREATE INDEX IF NOT EXISTS idx_orders_status ON orders(status)`,
 		`CREATE INDEX IF NOT EXISTS idx_orders_symbol ON orders(symbol)`,
 		`CREATE INDEX IF NOT EXISTS idx_trades_symbol ON trades(symbol)`,
@@ -140,4 +313,47 @@ type Trade struct {
 	FeeCurrency string
 	ExecutedAt  time.Time
 	Side        order.Side
+}
+
+// Transfer represents a movement of funds between exchanges
+type Transfer struct {
+	ID           string
+	WithdrawalID string // reference assigned by the sending exchange
+	FromExchange string
+	ToExchange   string
+	Asset        string
+	Network      string
+	Address      string
+	Amount       float64
+	Fee          float64
+	TxID         string
+	Status       int // exchange.TransferStatus
+	DryRun       bool
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Kline represents a historical OHLCV candle
+type Kline struct {
+	Exchange string
+	Symbol   string
+	Interval time.Duration
+	OpenTime time.Time
+	Open     float64
+	High     float64
+	Low      float64
+	Close    float64
+	Volume   float64
+	Trades   int64
+}
+
+// MarketTrade represents a historical public trade
+type MarketTrade struct {
+	Exchange   string
+	Symbol     string
+	TradeID    string
+	Price      float64
+	Quantity   float64
+	Side       order.Side
+	ExecutedAt time.Time
 }
\ No newline at end of file
@@ -15,6 +15,11 @@ impl CircuitBreaker {
         }
     }
 
+    /// Replace the parameters the limits are derived from
+    pub fn set_risk_params(&mut self, risk_params: RiskParameters) {
+        self.risk_params = risk_params;
+    }
+
     /// Check all risk limits and activate circuit breaker if any are breached
     pub fn check_limits(&mut self, daily_loss: f64, monthly_drawdown: f64) -> Result<bool> {
         // Check daily loss limit
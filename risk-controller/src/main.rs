@@ -45,6 +45,11 @@ async fn validate_trade(
     let risk_params = data.risk_params.lock().unwrap().clone();
     let mut circuit_breaker = data.circuit_breaker.lock().unwrap();
     
+    // Limits are a share of the account, so nothing passes until it is known
+    if risk_params.account_balance <= 0.0 {
+        return HttpResponse::ServiceUnavailable().body("Account balance not reported yet");
+    }
+    
     // Check circuit breaker first
     if circuit_breaker.is_activated().unwrap_or(true) {
         return HttpResponse::Forbidden().body("Circuit breaker activated - trading halted");
@@ -88,10 +93,18 @@ async fn update_risk_params(
     if let Some(pct) = update.monthly_drawdown_pct {
         risk_params.monthly_drawdown_pct = pct;
     }
+    data.circuit_breaker.lock().unwrap().set_risk_params(risk_params.clone());
     
     HttpResponse::Ok().body("Risk parameters updated")
 }
 
+async fn get_risk_params(
+    data: web::Data<AppState>,
+) -> impl Responder {
+    let risk_params = data.risk_params.lock().unwrap().clone();
+    HttpResponse::Ok().json(risk_params)
+}
+
 async fn get_circuit_breaker_status(
     data: web::Data<AppState>,
 ) -> impl Responder {
@@ -139,7 +152,12 @@ async fn main() -> std::io::Result<()> {
     dotenv::dotenv().ok();
     env_logger::init();
 
-    let account_balance = 2000.0; // Default balance, should come from config
+    // The execution engine reports the portfolio value through /risk_params;
+    // ACCOUNT_BALANCE only seeds it until the first report arrives
+    let account_balance = std::env::var("ACCOUNT_BALANCE")
+        .ok()
+        .and_then(|v| v.parse::<f64>().ok())
+        .unwrap_or(0.0);
     let risk_params = Arc::new(Mutex::new(RiskParameters::new(account_balance)));
     let redis_state = RedisState::new().expect("Failed to connect to Redis");
     let circuit_breaker = Arc::new(Mutex::new(CircuitBreaker::new(
@@ -161,6 +179,7 @@ async fn main() -> std::io::Result<()> {
             .app_data(app_state.clone())
             .route("/health", web::get().to(health_check))
             .route("/validate", web::post().to(validate_trade))
+            .route("/risk_params", web::get().to(get_risk_params))
             .route("/risk_params", web::put().to(update_risk_params))
             .route("/circuit_breaker", web::get().to(get_circuit_breaker_status))
             .route("/circuit_breaker/activate", web::post().to(activate_circuit_breaker))
@@ -1,11 +1,11 @@
-use serde::Deserialize;
+use serde::{Deserialize, Serialize};
 
-#[derive(Debug, Deserialize, Clone)]
+#[derive(Debug, Serialize, Deserialize, Clone)]
 pub struct RiskParameters {
     pub position_sizing_pct: f64,      // Max 0.5% per trade
     pub daily_loss_limit_pct: f64,     // 2% = $40
     pub monthly_drawdown_pct: f64,     // 15% = $300
-    pub account_balance: f64,          // Current account balance
+    pub account_balance: f64,          // Portfolio value reported by the execution engine
 }
 
 impl RiskParameters {
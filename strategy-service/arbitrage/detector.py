@@ -1,15 +1,26 @@
+import aiohttp
 import asyncio
 import ccxt.async_support as ccxt
 import csv
+import logging
 import os
 from datetime import datetime
-from ..config import RISK_PARAMS, DATA_STORAGE_PATH
+from ..config import RISK_PARAMS, RISK_CONTROLLER_URL, DATA_STORAGE_PATH
+
+# Base tier taker rates, matching the execution engine's exchange.DefaultFees,
+# for markets that don't report one
+DEFAULT_TAKER_FEES = {
+    'binance': 0.0005,
+    'kraken': 0.0040,
+    'coinbase': 0.0080,
+}
 
 class ArbitrageDetector:
     def __init__(self, exchanges):
         self.exchanges = {exch: getattr(ccxt, exch)(config) for exch, config in exchanges.items()}
         self.symbols = self._get_common_symbols()
         self.data_path = DATA_STORAGE_PATH
+        self.account_balance = None  # portfolio value, from the risk controller
         
     def _get_common_symbols(self):
         # Get common symbols across all exchanges
@@ -25,7 +36,16 @@ class ArbitrageDetector:
             tasks.append(exchange.fetch_order_book(symbol))
         return await asyncio.gather(*tasks, return_exceptions=True)
     
-    def calculate_arbitrage(self, order_books):
+    def taker_fee(self, exchange_name, symbol):
+        # Fee rate from the exchange's market data, as a fraction of notional
+        market = self.exchanges[exchange_name].markets.get(symbol, {})
+        fee = market.get('taker')
+        if fee is None:
+            # Unknown venues are assumed to charge the highest known rate
+            fee = DEFAULT_TAKER_FEES.get(exchange_name, max(DEFAULT_TAKER_FEES.values()))
+        return fee
+    
+    def calculate_arbitrage(self, symbol, order_books):
         best_bid = 0
         best_ask = float('inf')
         bid_exchange = None
@@ -48,25 +68,47 @@ class ArbitrageDetector:
                 ask_exchange = exchange_name
                 
         spread = best_bid - best_ask
+        
+        # Both legs cross the spread, so both pay the taker fee
+        fees = 0
+        if bid_exchange and ask_exchange:
+            fees = (best_bid * self.taker_fee(bid_exchange, symbol) +
+                    best_ask * self.taker_fee(ask_exchange, symbol))
         return {
             'symbol': symbol,
             'bid_exchange': bid_exchange,
             'bid_price': best_bid,
             'ask_exchange': ask_exchange,
             'ask_price': best_ask,
-            'spread': spread
+            'spread': spread,
+            'fees': fees,
+            'net_spread': spread - fees
         }
     
+    async def refresh_account_balance(self):
+        # The execution engine reports the portfolio value to the risk
+        # controller; keep the last known value if it cannot be reached
+        try:
+            async with aiohttp.ClientSession() as session:
+                async with session.get(f"{RISK_CONTROLLER_URL}/risk_params") as resp:
+                    resp.raise_for_status()
+                    params = await resp.json()
+            balance = params.get('account_balance') or 0
+            self.account_balance = balance if balance > 0 else None
+        except (aiohttp.ClientError, asyncio.TimeoutError, ValueError) as e:
+            logging.warning("Failed to fetch account balance: %s", e)
+    
     def check_risk(self, opportunity):
         # Implement risk management rules
         max_trade_value = RISK_PARAMS['max_trade_value']
         max_trade_percentage = RISK_PARAMS['max_trade_percentage']
         
-        # Placeholder - actual implementation would use account balance
-        account_balance = 2000  # Example balance
-        max_trade = min(max_trade_value, account_balance * max_trade_percentage)
+        # Trade sizes are a share of the account, so nothing passes until it is known
+        if self.account_balance is None:
+            return False
+        max_trade = min(max_trade_value, self.account_balance * max_trade_percentage)
         
-        return opportunity['spread'] > 0 and max_trade >= 10
+        return opportunity['net_spread'] > 0 and max_trade >= 10
     
     def log_opportunity(self, opportunity):
         os.makedirs(self.data_path, exist_ok=True)
@@ -80,9 +122,10 @@ class ArbitrageDetector:
             writer.writerow(opportunity)
     
     async def detect_opportunities(self):
+        await self.refresh_account_balance()
         for symbol in self.symbols:
             order_books = await self.fetch_order_books(symbol)
-            opportunity = self.calculate_arbitrage(order_books)
+            opportunity = self.calculate_arbitrage(symbol, order_books)
             
             if opportunity and self.check_risk(opportunity):
                 self.log_opportunity(opportunity)
@@ -23,4 +23,7 @@ RISK_PARAMS = {
     'max_trade_value': 10.0  # $10
 }
 
+# Serves the portfolio value reported by the execution engine
+RISK_CONTROLLER_URL = os.getenv('RISK_CONTROLLER_URL', 'http://localhost:8080')
+
 DATA_STORAGE_PATH = './data/historical/'
\ No newline at end of file